@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// snapshot returns the state of the entity as flattened json fields, e.g. address.city
+func snapshot(entity interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	flatten("", v, fields)
+	return fields, nil
+}
+
+func flatten(path string, v interface{}, fields map[string]interface{}) {
+	m, ok := v.(map[string]interface{})
+	if !ok || (len(m) == 0 && path != "") {
+		fields[path] = v
+		return
+	}
+
+	for k, child := range m {
+		if path != "" {
+			k = path + "." + k
+		}
+		flatten(k, child, fields)
+	}
+}
+
+// diff returns the changed fields, sorted by field
+func diff(before, after map[string]interface{}) []FieldChange {
+	changes := []FieldChange{}
+	for field, b := range before {
+		if a, ok := after[field]; !ok || !reflect.DeepEqual(a, b) {
+			changes = append(changes, FieldChange{Field: field, Before: b, After: a})
+		}
+	}
+	for field, a := range after {
+		if _, ok := before[field]; !ok {
+			changes = append(changes, FieldChange{Field: field, After: a})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
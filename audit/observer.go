@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/command/middleware/authz"
+)
+
+// PrincipalFunc returns the ID of the principal executing a command
+type PrincipalFunc func(context.Context) string
+
+// Option configures the audit
+type Option func(*observer)
+
+// WithPrincipal sets the PrincipalFunc, default the ID of the authz.Principal of the context
+func WithPrincipal(fn PrincipalFunc) Option {
+	return func(o *observer) {
+		o.principal = fn
+	}
+}
+
+// WithClock sets the function returning the current time, useful in tests
+func WithClock(now func() time.Time) Option {
+	return func(o *observer) {
+		o.now = now
+	}
+}
+
+type observer struct {
+	store     Store
+	principal PrincipalFunc
+	now       func() time.Time
+}
+
+var _ = command.EntityObserver(&observer{})
+
+// NewObserver returns an EntityObserver appending an audit record to the store for every entity
+// created, updated or removed by a committed command, see command.WithEntityObserver. The entities are compared
+// by their json encoding
+func NewObserver(store Store, options ...Option) command.EntityObserver {
+	o := &observer{
+		store: store,
+		principal: func(ctx context.Context) string {
+			p, _ := authz.PrincipalFromContext(ctx)
+			return p.ID
+		},
+		now: time.Now,
+	}
+	for _, option := range options {
+		option(o)
+	}
+	return o
+}
+
+// ObserveEntity implements the command.EntityObserver interface
+func (o *observer) ObserveEntity(ctx context.Context, cmd command.Command, entity command.Entity, change command.EntityChange) func(error) error {
+	before := map[string]interface{}{}
+	var snapshotErr error
+	if change != command.EntityCreated {
+		before, snapshotErr = snapshot(entity)
+	}
+
+	return func(err error) error {
+		if err != nil {
+			return nil
+		}
+		if snapshotErr != nil {
+			return snapshotErr
+		}
+
+		after := map[string]interface{}{}
+		if change != command.EntityRemoved {
+			if after, err = snapshot(entity); err != nil {
+				return err
+			}
+		}
+
+		tenant, _ := command.TenantFromContext(ctx)
+		return o.store.Append(ctx, Record{
+			CommandType: cmd.CommandType(),
+			EntityType:  entityType(entity),
+			EntityID:    entity.EntityID(),
+			Change:      change,
+			PrincipalID: o.principal(ctx),
+			TenantID:    tenant,
+			Metadata:    command.MetadataFromContext(ctx),
+			Timestamp:   o.now(),
+			Diff:        diff(before, after),
+		})
+	}
+}
+
+func entityType(entity command.Entity) string {
+	t := reflect.TypeOf(entity)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
@@ -0,0 +1,185 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/command/middleware/authz"
+	"github.com/gapsquare/command/repository/memrepo"
+
+	"github.com/gapsquare/command/mocks"
+)
+
+const (
+	setType    = command.Type("audit.set")
+	removeType = command.Type("audit.remove")
+)
+
+type setCommand struct {
+	ID      int
+	Content string
+	entity  *mocks.SimpleModel
+}
+
+func (c *setCommand) CommandType() command.Type { return setType }
+func (c *setCommand) Entity() command.Entity {
+	if c.entity == nil {
+		c.entity = &mocks.SimpleModel{ID: c.ID}
+	}
+	return c.entity
+}
+
+type removeCommand struct {
+	ID int
+}
+
+func (c *removeCommand) CommandType() command.Type { return removeType }
+func (c *removeCommand) Entity() command.Entity    { return &mocks.SimpleModel{ID: c.ID} }
+
+var errSet error
+
+func init() {
+	command.RegisterCommandHandler(setType, &mocks.MockCommandHandler{BuFn: func(c command.Command, e command.Entity) error {
+		if errSet != nil {
+			return errSet
+		}
+		e.(*mocks.SimpleModel).Content = c.(*setCommand).Content
+		return nil
+	}})
+	command.RegisterCommandHandler(removeType, command.NewDestructiveHandler(&mocks.MockCommandHandler{},
+		func(ctx context.Context, cmd command.Command) error {
+			return repository.Remove(cmd.Entity())
+		}))
+}
+
+var repository = memrepo.NewRepository()
+
+func TestObserver_Records(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Unix(100, 0)
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(mocks.MockCommandStore{}),
+		command.WithEventBus(&mocks.EventBus{}),
+		command.WithEntityObserver(NewObserver(store, WithClock(func() time.Time { return now }))),
+	), repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := authz.WithPrincipal(context.Background(), authz.Principal{ID: "alice"})
+	for _, cmd := range []command.Command{
+		&setCommand{ID: 1, Content: "a"},
+		&setCommand{ID: 1, Content: "b"},
+		&removeCommand{ID: 1},
+	} {
+		if err := ce.Execute(ctx, cmd); err != nil {
+			t.Fatal("there should be no error:", err)
+		}
+	}
+
+	errSet = errors.New("can not set")
+	defer func() { errSet = nil }()
+	if err := ce.Execute(ctx, &setCommand{ID: 2, Content: "c"}); err != errSet {
+		t.Fatal("the handler error should be returned:", err)
+	}
+
+	records := store.Records("1")
+	if len(records) != 3 || len(store.Records("")) != 3 {
+		t.Fatal("there should be a record per successful command:", records)
+	}
+
+	expected := []struct {
+		change command.EntityChange
+		diff   []FieldChange
+	}{
+		{command.EntityCreated, []FieldChange{{Field: "content", After: "a"}, {Field: "id", After: 1.0}}},
+		{command.EntityUpdated, []FieldChange{{Field: "content", Before: "a", After: "b"}}},
+		{command.EntityRemoved, []FieldChange{{Field: "content", Before: "b"}, {Field: "id", Before: 1.0}}},
+	}
+	for i, r := range records {
+		if r.Change != expected[i].change || !reflect.DeepEqual(r.Diff, expected[i].diff) {
+			t.Error("unexpected record:", r.Change, r.Diff)
+		}
+		if r.PrincipalID != "alice" || r.EntityType != "SimpleModel" || !r.Timestamp.Equal(now) {
+			t.Error("the record should describe the principal and the entity:", r)
+		}
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Append(context.Context, Record) error { return errors.New("store unavailable") }
+
+func TestObserver_StoreErrorFailsExecution(t *testing.T) {
+	repository := memrepo.NewRepository()
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(mocks.MockCommandStore{}),
+		command.WithEventBus(&mocks.EventBus{}),
+		command.WithEntityObserver(NewObserver(failingStore{})),
+	), repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ce.Execute(context.Background(), &setCommand{ID: 1, Content: "a"}); err == nil {
+		t.Error("the store error should fail the execution")
+	}
+}
+
+// commitFailingRepository is a transactional repository whose transactions fail to commit
+type commitFailingRepository struct {
+	*memrepo.Repository
+}
+
+func (r commitFailingRepository) BeginTransaction(context.Context) (command.Transaction, error) {
+	return commitFailingTx{r.Repository}, nil
+}
+
+type commitFailingTx struct {
+	*memrepo.Repository
+}
+
+var errCommit = errors.New("commit failed")
+
+func (commitFailingTx) Commit() error   { return errCommit }
+func (commitFailingTx) Rollback() error { return nil }
+
+func TestObserver_NoRecordWhenCommitFails(t *testing.T) {
+	store := NewMemoryStore()
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(mocks.MockCommandStore{}),
+		command.WithEventBus(&mocks.EventBus{}),
+		command.WithEntityObserver(NewObserver(store)),
+	), commitFailingRepository{memrepo.NewRepository()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ce.Execute(context.Background(), &setCommand{ID: 1, Content: "a"}); !errors.Is(err, errCommit) {
+		t.Error("the commit error should be returned:", err)
+	}
+	if records := store.Records(""); len(records) != 0 {
+		t.Error("there should be no record of a change which was not committed:", records)
+	}
+}
+
+func TestDiff_NestedFields(t *testing.T) {
+	type address struct {
+		City string `json:"city"`
+	}
+	type customer struct {
+		Name    string  `json:"name"`
+		Address address `json:"address"`
+	}
+
+	before, _ := snapshot(customer{Name: "a", Address: address{City: "Paris"}})
+	after, _ := snapshot(customer{Name: "a", Address: address{City: "Lyon"}})
+	changes := diff(before, after)
+	if len(changes) != 1 || changes[0].Field != "address.city" || changes[0].After != "Lyon" {
+		t.Error("the nested field should be diffed:", changes)
+	}
+}
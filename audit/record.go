@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gapsquare/command"
+)
+
+// Record is the audit record of an entity changed by a command
+type Record struct {
+	CommandType command.Type         `json:"command_type"`
+	EntityType  string               `json:"entity_type"`
+	EntityID    command.EntityID     `json:"entity_id"`
+	Change      command.EntityChange `json:"change"`
+	// PrincipalID is the principal executing the command, empty if unknown
+	PrincipalID string           `json:"principal_id,omitempty"`
+	TenantID    command.TenantID `json:"tenant_id,omitempty"`
+	Metadata    command.Metadata `json:"metadata,omitempty"`
+	Timestamp   time.Time        `json:"timestamp"`
+	Diff        []FieldChange    `json:"diff"`
+}
+
+// FieldChange is the change of a field, Before is nil for added fields and After for removed ones
+type FieldChange struct {
+	// Field is the path of the field, e.g. address.city, it uses the json name of the field
+	Field  string      `json:"field"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// Store stores the audit records
+type Store interface {
+	// Append appends a record, it is called once the transaction of the execution is committed
+	Append(context.Context, Record) error
+}
+
+// MemoryStore is an in-memory Store
+type MemoryStore struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+var _ = Store(&MemoryStore{})
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Append implements the Store interface
+func (s *MemoryStore) Append(_ context.Context, r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, r)
+	return nil
+}
+
+// Records returns the records of the entity, all records if id is empty
+func (s *MemoryStore) Records(id command.EntityID) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []Record
+	for _, r := range s.records {
+		if id.IsEmpty() || r.EntityID == id {
+			records = append(records, r)
+		}
+	}
+	return records
+}
@@ -0,0 +1,66 @@
+package bus
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gapsquare/command"
+)
+
+// ErrAlreadyAcknowledged error when a delivery is acked or nacked twice
+var ErrAlreadyAcknowledged = errors.New("Delivery already acknowledged")
+
+// ErrTransportClosed error when sending on a closed transport
+var ErrTransportClosed = errors.New("Transport closed")
+
+// Message is a command sent on the bus
+type Message struct {
+	ID       string           `json:"id"`
+	Envelope command.Envelope `json:"envelope"`
+	// Attempt is the number of deliveries of the message, set by the transport
+	Attempt int `json:"attempt"`
+}
+
+// Delivery is a message delivered by a Transport, it must be acked or nacked once
+type Delivery interface {
+	Message() Message
+	// Ack acknowledges the message, it is not delivered again
+	Ack() error
+	// Nack rejects the message, it is delivered again if requeue is true
+	Nack(requeue bool) error
+}
+
+// Transport sends and receives the messages of the bus, e.g. with NATS or Kafka.
+// A message is delivered to a single subscriber
+type Transport interface {
+	// Publish sends a message
+	Publish(context.Context, Message) error
+	// Subscribe returns the deliveries, the channel is closed when the context is done or the transport is closed
+	Subscribe(context.Context) (<-chan Delivery, error)
+}
+
+// CommandBus sends commands to be executed by a Consumer, possibly in another process
+type CommandBus interface {
+	// Send sends the command with the metadata and the tenant of the context
+	Send(context.Context, command.Command) error
+}
+
+type commandBus struct {
+	transport Transport
+}
+
+// NewCommandBus creates a CommandBus sending the commands on the transport. The commands are encoded in
+// a command.Envelope, their factory must be registered with command.RegisterCommand on the consumer side
+func NewCommandBus(transport Transport) CommandBus {
+	return &commandBus{transport: transport}
+}
+
+// Send implements the CommandBus interface
+func (b *commandBus) Send(ctx context.Context, cmd command.Command) error {
+	envelope, err := command.NewEnvelope(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	return b.transport.Publish(ctx, Message{ID: command.NewUUIDEntityID().String(), Envelope: envelope})
+}
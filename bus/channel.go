@@ -0,0 +1,163 @@
+package bus
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RedeliveryDelayFunc returns the delay before a nacked message is delivered again, attempt is the number
+// of deliveries of the message so far
+type RedeliveryDelayFunc func(attempt int) time.Duration
+
+// DefaultRedeliveryDelay doubles the delay from 100ms on every attempt, up to 10s
+func DefaultRedeliveryDelay(attempt int) time.Duration {
+	const initial, max = 100 * time.Millisecond, 10 * time.Second
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt > 8 {
+		return max
+	}
+	if delay := initial << (attempt - 1); delay < max {
+		return delay
+	}
+	return max
+}
+
+// ChannelOption configures a ChannelTransport
+type ChannelOption func(*ChannelTransport)
+
+// WithRedeliveryDelay sets the delay before a nacked message is delivered again, default DefaultRedeliveryDelay
+func WithRedeliveryDelay(delay RedeliveryDelayFunc) ChannelOption {
+	return func(t *ChannelTransport) {
+		t.redeliveryDelay = delay
+	}
+}
+
+// ChannelTransport is an in-process Transport, the messages are delivered to the subscribers of the transport
+type ChannelTransport struct {
+	messages        chan Message
+	closing         chan struct{}
+	once            sync.Once
+	redeliveryDelay RedeliveryDelayFunc
+}
+
+var _ = Transport(&ChannelTransport{})
+
+// NewChannelTransport creates a ChannelTransport buffering size messages
+func NewChannelTransport(size int, options ...ChannelOption) *ChannelTransport {
+	t := &ChannelTransport{
+		messages:        make(chan Message, size),
+		closing:         make(chan struct{}),
+		redeliveryDelay: DefaultRedeliveryDelay,
+	}
+	for _, option := range options {
+		option(t)
+	}
+	return t
+}
+
+// Publish implements the Transport interface, it blocks while the buffer is full
+func (t *ChannelTransport) Publish(ctx context.Context, msg Message) error {
+	select {
+	case <-t.closing:
+		return ErrTransportClosed
+	default:
+	}
+
+	select {
+	case t.messages <- msg:
+		return nil
+	case <-t.closing:
+		return ErrTransportClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Subscribe implements the Transport interface
+func (t *ChannelTransport) Subscribe(ctx context.Context) (<-chan Delivery, error) {
+	deliveries := make(chan Delivery)
+	go func() {
+		defer close(deliveries)
+		for {
+			select {
+			case msg := <-t.messages:
+				delivered := msg
+				delivered.Attempt++
+				select {
+				case deliveries <- &channelDelivery{transport: t, msg: delivered}:
+				case <-ctx.Done():
+					// the message was not delivered
+					t.requeue(msg, 0)
+					return
+				case <-t.closing:
+					return
+				}
+			case <-ctx.Done():
+				return
+			case <-t.closing:
+				return
+			}
+		}
+	}()
+	return deliveries, nil
+}
+
+// Close closes the transport, the subscriptions are closed and the pending messages are dropped
+func (t *ChannelTransport) Close() error {
+	t.once.Do(func() { close(t.closing) })
+	return nil
+}
+
+// requeue publishes a message again after the delay without blocking the caller,
+// the message is dropped if the transport is closed meanwhile
+func (t *ChannelTransport) requeue(msg Message, delay time.Duration) {
+	go func() {
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-t.closing:
+				return
+			}
+		}
+		t.Publish(context.Background(), msg)
+	}()
+}
+
+type channelDelivery struct {
+	transport *ChannelTransport
+	msg       Message
+
+	mu   sync.Mutex
+	done bool
+}
+
+func (d *channelDelivery) Message() Message { return d.msg }
+
+func (d *channelDelivery) Ack() error {
+	return d.acknowledge(func() {})
+}
+
+func (d *channelDelivery) Nack(requeue bool) error {
+	return d.acknowledge(func() {
+		if requeue {
+			d.transport.requeue(d.msg, d.transport.redeliveryDelay(d.msg.Attempt))
+		}
+	})
+}
+
+func (d *channelDelivery) acknowledge(fn func()) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.done {
+		return ErrAlreadyAcknowledged
+	}
+	d.done = true
+	fn()
+	return nil
+}
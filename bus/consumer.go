@@ -0,0 +1,173 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/gapsquare/command"
+
+	"github.com/gapsquare/command/middleware/authz"
+	"github.com/gapsquare/command/middleware/validator"
+)
+
+// MetadataMessageID is the metadata key of the ID of the message executed by a Consumer
+const MetadataMessageID = "message_id"
+
+// DeadLetterSink receives the messages which can not be executed
+type DeadLetterSink interface {
+	// DeadLetter stores the message with the error of its last attempt
+	DeadLetter(context.Context, Message, error) error
+}
+
+// RetryableFunc returns true if an execution failing with the error can be retried
+type RetryableFunc func(error) bool
+
+// DefaultRetryable retries every error, except the permanent ones which fail the same way on every attempt:
+// the errors decoding the command, validation, authorization, tenant and precondition errors,
+// version mismatches and unregistered commands
+func DefaultRetryable(err error) bool {
+	var (
+		decodeErr       *DecodeError
+		validationErrs  validator.ValidationErrors
+		fieldErr        validator.FieldError
+		preconditionErr *command.PreconditionError
+	)
+
+	switch {
+	case errors.As(err, &decodeErr), errors.As(err, &validationErrs), errors.As(err, &fieldErr),
+		errors.As(err, &preconditionErr):
+		return false
+	case errors.Is(err, authz.ErrUnauthorized), errors.Is(err, authz.ErrForbidden),
+		errors.Is(err, command.ErrTenantMissing), errors.Is(err, command.ErrTenantMismatch):
+		return false
+	case errors.Is(err, command.ErrVersionMismatched), errors.Is(err, command.ErrCommandNotRegistered),
+		errors.Is(err, command.ErrCommandHandlerNotRegistered):
+		return false
+	}
+	return true
+}
+
+// DecodeError error when the command of a message can not be decoded
+type DecodeError struct {
+	Err error
+}
+
+// Error implements the error interface
+func (e *DecodeError) Error() string {
+	return "can not decode command: " + e.Err.Error()
+}
+
+// Unwrap returns the decoding error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ConsumerOption configures a Consumer
+type ConsumerOption func(*Consumer)
+
+// WithMaxAttempts sets the number of attempts of a message before it is dead-lettered, default 3
+func WithMaxAttempts(n int) ConsumerOption {
+	return func(c *Consumer) {
+		c.maxAttempts = n
+	}
+}
+
+// WithDeadLetterSink sets the sink of the messages failing after the last attempt,
+// without sink they are nacked without requeue
+func WithDeadLetterSink(sink DeadLetterSink) ConsumerOption {
+	return func(c *Consumer) {
+		c.sink = sink
+	}
+}
+
+// WithRetryable sets the RetryableFunc, default DefaultRetryable
+func WithRetryable(fn RetryableFunc) ConsumerOption {
+	return func(c *Consumer) {
+		c.retryable = fn
+	}
+}
+
+// WithConcurrency sets the number of messages executed concurrently, default 1
+func WithConcurrency(n int) ConsumerOption {
+	return func(c *Consumer) {
+		c.concurrency = n
+	}
+}
+
+// Consumer executes the messages of a transport with an Executer
+type Consumer struct {
+	transport   Transport
+	executer    command.Executer
+	maxAttempts int
+	sink        DeadLetterSink
+	retryable   RetryableFunc
+	concurrency int
+}
+
+// NewConsumer creates a Consumer executing the messages of the transport
+func NewConsumer(transport Transport, executer command.Executer, options ...ConsumerOption) *Consumer {
+	c := &Consumer{
+		transport:   transport,
+		executer:    executer,
+		maxAttempts: 3,
+		retryable:   DefaultRetryable,
+		concurrency: 1,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Run executes the messages until the context is done or the transport is closed. A message is acked when it is
+// executed, nacked to be redelivered when it fails and has attempts left, or dead-lettered otherwise
+func (c *Consumer) Run(ctx context.Context) error {
+	deliveries, err := c.transport.Subscribe(ctx)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range deliveries {
+				c.Handle(ctx, d)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// Handle executes a delivery, it returns the error of the acknowledgement
+func (c *Consumer) Handle(ctx context.Context, d Delivery) error {
+	msg := d.Message()
+	ctx = command.WithMetadata(msg.Envelope.Context(ctx), MetadataMessageID, msg.ID)
+
+	cmd, err := msg.Envelope.Command()
+	if err != nil {
+		err = &DecodeError{Err: err}
+	} else {
+		err = c.executer.Execute(ctx, cmd)
+	}
+
+	if err == nil {
+		return d.Ack()
+	}
+
+	if c.retryable(err) && msg.Attempt < c.maxAttempts {
+		return d.Nack(true)
+	}
+
+	if c.sink == nil {
+		return d.Nack(false)
+	}
+	if e := c.sink.DeadLetter(ctx, msg, err); e != nil {
+		return d.Nack(true)
+	}
+	return d.Ack()
+}
@@ -0,0 +1,229 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gapsquare/command"
+
+	"github.com/gapsquare/command/middleware/authz"
+	"github.com/gapsquare/command/middleware/validator"
+	"github.com/gapsquare/command/mocks"
+)
+
+const chargeType = command.Type("bus.charge")
+
+type chargeCommand struct {
+	ID     int `json:"id"`
+	Amount int `json:"amount"`
+}
+
+func (c *chargeCommand) CommandType() command.Type { return chargeType }
+func (c *chargeCommand) Entity() command.Entity    { return &mocks.SimpleModel{ID: c.ID} }
+
+func init() {
+	command.RegisterCommand(chargeType, func() command.Command { return &chargeCommand{} })
+}
+
+// executer fails the first failures executions
+type executer struct {
+	mu       sync.Mutex
+	failures int
+	executed []*chargeCommand
+	tenants  []command.TenantID
+	done     chan struct{}
+}
+
+var errCharge = errors.New("can not charge")
+
+func (e *executer) Execute(ctx context.Context, cmd command.Command) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer func() { e.done <- struct{}{} }()
+
+	if e.failures > 0 {
+		e.failures--
+		return errCharge
+	}
+	tenant, _ := command.TenantFromContext(ctx)
+	e.executed = append(e.executed, cmd.(*chargeCommand))
+	e.tenants = append(e.tenants, tenant)
+	return nil
+}
+
+type sink struct {
+	mu       sync.Mutex
+	messages []Message
+	errs     []error
+	done     chan struct{}
+}
+
+func (s *sink) DeadLetter(ctx context.Context, msg Message, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	s.errs = append(s.errs, err)
+	s.done <- struct{}{}
+	return nil
+}
+
+func wait(t *testing.T, ch chan struct{}, n int) {
+	for i := 0; i < n; i++ {
+		select {
+		case <-ch:
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for", n, "signals")
+		}
+	}
+}
+
+func run(t *testing.T, transport Transport, e command.Executer, options ...ConsumerOption) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		NewConsumer(transport, e, options...).Run(ctx)
+		close(stopped)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-stopped
+	})
+}
+
+func TestConsumer_ExecutesWithRedelivery(t *testing.T) {
+	transport := NewChannelTransport(10)
+	e := &executer{failures: 2, done: make(chan struct{}, 10)}
+	s := &sink{done: make(chan struct{}, 10)}
+	run(t, transport, e, WithMaxAttempts(3), WithDeadLetterSink(s))
+
+	ctx := command.WithTenant(context.Background(), "acme")
+	if err := NewCommandBus(transport).Send(ctx, &chargeCommand{ID: 1, Amount: 10}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	wait(t, e.done, 3)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.executed) != 1 || e.executed[0].Amount != 10 || e.tenants[0] != "acme" {
+		t.Error("the command should be executed on the third attempt with its tenant:", e.executed, e.tenants)
+	}
+	if len(s.messages) != 0 {
+		t.Error("the message should not be dead-lettered:", s.messages)
+	}
+}
+
+func TestConsumer_DeadLetters(t *testing.T) {
+	transport := NewChannelTransport(10)
+	e := &executer{failures: 10, done: make(chan struct{}, 10)}
+	s := &sink{done: make(chan struct{}, 10)}
+	run(t, transport, e, WithMaxAttempts(2), WithDeadLetterSink(s))
+
+	bus := NewCommandBus(transport)
+	bus.Send(context.Background(), &chargeCommand{ID: 1})
+	transport.Publish(context.Background(), Message{ID: "invalid", Envelope: command.Envelope{Type: "unknown"}})
+	wait(t, s.done, 2)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, msg := range s.messages {
+		switch msg.ID {
+		case "invalid":
+			var decodeErr *DecodeError
+			if msg.Attempt != 1 || !errors.As(s.errs[i], &decodeErr) {
+				t.Error("messages which can not be decoded should not be retried:", msg, s.errs[i])
+			}
+		default:
+			if msg.Attempt != 2 || s.errs[i] != errCharge {
+				t.Error("the message should be dead-lettered after 2 attempts:", msg, s.errs[i])
+			}
+		}
+	}
+}
+
+func TestChannelTransport_Acknowledgement(t *testing.T) {
+	transport := NewChannelTransport(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	deliveries, _ := transport.Subscribe(ctx)
+	transport.Publish(ctx, Message{ID: "1"})
+
+	d := <-deliveries
+	if err := d.Nack(true); err != nil {
+		t.Error("there should be no error:", err)
+	}
+	if err := d.Ack(); err != ErrAlreadyAcknowledged {
+		t.Error("a delivery should be acknowledged once:", err)
+	}
+
+	d = <-deliveries
+	if d.Message().ID != "1" || d.Message().Attempt != 2 {
+		t.Error("the nacked message should be redelivered:", d.Message())
+	}
+	d.Ack()
+
+	transport.Close()
+	if _, ok := <-deliveries; ok {
+		t.Error("the deliveries should be closed with the transport")
+	}
+	if err := transport.Publish(ctx, Message{}); err != ErrTransportClosed {
+		t.Error("publishing on a closed transport should fail:", err)
+	}
+}
+
+func TestDefaultRetryable(t *testing.T) {
+	permanent := []error{
+		&DecodeError{Err: errCharge},
+		validator.ValidationErrors{{Field: "amount", Code: validator.CodeMin}},
+		&authz.Error{Err: authz.ErrForbidden},
+		&command.PreconditionError{CommandType: chargeType, Err: errCharge},
+		command.ErrVersionMismatched,
+	}
+	for _, err := range permanent {
+		if DefaultRetryable(err) {
+			t.Error("the error should not be retried:", err)
+		}
+	}
+
+	for _, err := range []error{errCharge, command.ErrConcurrencyConflict, context.DeadlineExceeded} {
+		if !DefaultRetryable(err) {
+			t.Error("the error should be retried:", err)
+		}
+	}
+}
+
+func TestChannelTransport_RedeliveryDelay(t *testing.T) {
+	delays := make(chan int, 1)
+	transport := NewChannelTransport(1, WithRedeliveryDelay(func(attempt int) time.Duration {
+		delays <- attempt
+		return 50 * time.Millisecond
+	}))
+	defer transport.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	deliveries, _ := transport.Subscribe(ctx)
+	transport.Publish(ctx, Message{ID: "1"})
+
+	(<-deliveries).Nack(true)
+	nacked := time.Now()
+	if attempt := <-delays; attempt != 1 {
+		t.Error("the delay should be computed for the delivered attempt:", attempt)
+	}
+
+	d := <-deliveries
+	if elapsed := time.Since(nacked); elapsed < 50*time.Millisecond {
+		t.Error("the nacked message should be redelivered after the delay:", elapsed)
+	}
+	if d.Message().Attempt != 2 {
+		t.Error("the nacked message should be redelivered:", d.Message())
+	}
+
+	if DefaultRedeliveryDelay(1) != 100*time.Millisecond || DefaultRedeliveryDelay(3) != 400*time.Millisecond ||
+		DefaultRedeliveryDelay(100) != 10*time.Second {
+		t.Error("the default delay should double on every attempt up to 10s")
+	}
+}
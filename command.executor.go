@@ -5,20 +5,42 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/gapsquare/goevent"
 )
 
-// Executer an interface that is responsable to execute a command
+// Executer an interface that is responsable to execute a command.
+//
+// A command handled by a constructive handler creates its entity when the repository returns ErrEntityNotFound:
+// the handler receives the zero entity of the command, with its EntityID, and the executer saves it.
+// This also applies to update commands sent with an unknown EntityID, a command that must not create its entity
+// should be Versionable, a not found entity has version 0, or its handler should reject the zero entity
 type Executer interface {
 	// Execute execute the command
 	Execute(context.Context, Command) error
 }
 
+var errRepositoryNotAssigningVersions = errors.New("repository does not assign versions")
+
 type commandExecuter struct {
 	repository ReadWriteRepository
 	store      Store
 	bus        goevent.EventBus
+
+	tokenComparer      TokenComparer
+	repositoryVersions bool
+
+	afterSave    []AfterSaveInterceptor
+	afterPublish []AfterPublishInterceptor
+
+	tenantIsolation bool
+
+	executionTimeout time.Duration
+
+	observer        observers
+	eventEnrichers  []EventEnricher
+	entityObservers []EntityObserver
 }
 
 // NewExecuter creates an instance of Executer
@@ -34,112 +56,358 @@ func NewExecuter(config configureOption, repository ReadWriteRepository) (Execut
 	if config.eventBus == nil {
 		return nil, errors.New("event bus is nil")
 	}
+
+	if config.repositoryVersions && !assignsVersions(repository) {
+		return nil, errRepositoryNotAssigningVersions
+	}
+
+	tokenComparer := config.tokenComparer
+	if tokenComparer == nil {
+		tokenComparer = DefaultTokenComparer
+	}
+
 	return &commandExecuter{
-		repository: repository,
-		store:      config.commandStore,
-		bus:        config.eventBus,
+		repository:         repository,
+		store:              config.commandStore,
+		bus:                config.eventBus,
+		tokenComparer:      tokenComparer,
+		repositoryVersions: config.repositoryVersions,
+		afterSave:          config.afterSave,
+		afterPublish:       config.afterPublish,
+		tenantIsolation:    config.tenantIsolation,
+		executionTimeout:   config.executionTimeout,
+		observer:           observers(config.observers),
+		eventEnrichers:     config.eventEnrichers,
+		entityObservers:    config.entityObservers,
 	}, nil
 }
 
-func (ce *commandExecuter) Execute(ctx context.Context, cmd Command) error {
+func (ce *commandExecuter) Execute(ctx context.Context, cmd Command) (err error) {
+	ctx, finish := ce.observer.ObserveExecution(ctx, cmd)
+	defer func() { finish(err) }()
+
+	var handler Handler
+	err = ce.stage(ctx, cmd, StageHandlerLookup, func(context.Context) error {
+		h, e := GetCommandHandler(cmd.CommandType())
+		if e != nil {
+			return fmt.Errorf("Can not find command handler for command %s, Error: %w", cmd.CommandType(), e)
+		}
+		handler = h
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, ok := TenantFromContext(ctx); ce.tenantIsolation && !ok {
+		return ErrTenantMissing
+	}
 
-	handler, err := GetCommandHandler(cmd.CommandType())
+	if ce.executionTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ce.executionTimeout)
+		defer cancel()
+	}
+
+	ctx, repository, done, err := ce.begin(ctx)
 	if err != nil {
-		return fmt.Errorf("Can not find command handler for command %s, Error: %v", cmd.CommandType(), err)
+		return err
 	}
 
+	var observed func(error) error
 	if dest, ok := handler.(*DestructiveHandler); ok {
-		if e := ce.executeDestructiveHandler(ctx, cmd, dest); e != nil {
+		observed, err = ce.executeDestructiveHandler(ctx, repository, cmd, dest)
+	} else {
+		observed, err = ce.executeConstructiveHandler(ctx, repository, cmd, handler)
+	}
+
+	if e := done(err); e != nil {
+		err = StageTimeout(cmd.CommandType(), StageRepositorySave, e)
+	}
+
+	// the entity observers see the result once the transaction is committed or rolled back
+	if observed != nil {
+		err = observed(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, interceptor := range ce.afterSave {
+		if e := interceptor(ctx, cmd); e != nil {
 			return e
 		}
-	} else {
-		if e := ce.executeConstructiveHandler(ctx, cmd, handler); e != nil {
+	}
+
+	events, err := ce.publishEvents(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	for _, interceptor := range ce.afterPublish {
+		if e := interceptor(ctx, cmd, events); e != nil {
 			return e
 		}
 	}
 
-	return ce.publishEvents(ctx, cmd)
+	return nil
+}
+
+// stage runs a stage of the execution, observed by the observers of the executer.
+// It fails with a *TimeoutError if the deadline is exceeded before or during the stage
+func (ce *commandExecuter) stage(ctx context.Context, cmd Command, stage Stage, fn func(context.Context) error) (err error) {
+	ctx, finish := ce.observer.ObserveStage(ctx, cmd, stage)
+	defer func() { finish(err) }()
+
+	if err := ctx.Err(); err != nil {
+		return StageTimeout(cmd.CommandType(), stage, err)
+	}
+	return StageTimeout(cmd.CommandType(), stage, fn(ctx))
+}
+
+// assignsVersions returns true if the repository and its tenant partitions implement VersionAssigningRepository,
+// the transactions of a TransactionalRepository are checked when they begin
+func assignsVersions(repository ReadWriteRepository) bool {
+	if _, ok := repository.(VersionAssigningRepository); !ok {
+		return false
+	}
+
+	if tr, ok := repository.(TenantAwareRepository); ok {
+		if _, ok := tr.ForTenant("").(VersionAssigningRepository); !ok {
+			return false
+		}
+	}
+	return true
 }
 
-func (ce *commandExecuter) executeDestructiveHandler(ctx context.Context, cmd Command, handler Handler) error {
+// begin returns the repository used by an execution, scoped to the tenant of the context if the repository
+// is tenant aware. It starts a transaction if the repository is transactional, done commits the transaction,
+// or rolls it back if the execution failed
+func (ce *commandExecuter) begin(ctx context.Context) (context.Context, ReadWriteRepository, func(error) error, error) {
+	repository := ce.repository
+	if tr, ok := repository.(TenantAwareRepository); ok {
+		if tenant, ok := TenantFromContext(ctx); ok {
+			repository = tr.ForTenant(tenant)
+		}
+	}
+
+	tr, ok := repository.(TransactionalRepository)
+	if !ok {
+		return ctx, repository, func(err error) error { return err }, nil
+	}
+
+	tx, err := tr.BeginTransaction(ctx)
+	if err != nil {
+		return ctx, nil, nil, err
+	}
+
+	if _, ok := tx.(VersionAssigningRepository); ce.repositoryVersions && !ok {
+		tx.Rollback()
+		return ctx, nil, nil, errRepositoryNotAssigningVersions
+	}
+
+	done := func(err error) error {
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	}
+	return withTransaction(ctx, tx), tx, done, nil
+}
+
+func (ce *commandExecuter) executeDestructiveHandler(ctx context.Context, repository ReadWriteRepository, cmd Command, handler Handler) (observed func(error) error, err error) {
 	entity := cmd.Entity()
 
 	if entity == nil {
-		return fmt.Errorf("Can not run destructive action on a nil Entity for command type %s", cmd.CommandType())
+		return nil, fmt.Errorf("Can not run destructive action on a nil Entity for command type %s", cmd.CommandType())
 	}
 
-	err := ce.repository.Find(entity)
+	err = ce.stage(ctx, cmd, StageLoad, func(context.Context) error { return repository.Find(entity) })
+	if err == nil {
+		err = checkTenant(ctx, entity)
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if e := ce.store.Save(cmd, ce.repository); e != nil {
-		return e
+	if e := validateAgainst(cmd, entity); e != nil {
+		return nil, e
 	}
 
-	if e := handler.HandleCommand(ctx, cmd); e != nil {
-		return e
+	observed = ce.observeEntity(ctx, cmd, entity, EntityRemoved)
+	ctx = withEntity(ctx, entity)
+
+	if e := ce.stage(ctx, cmd, StageStoreSave, func(ctx context.Context) error { return ce.saveCommand(ctx, cmd, repository) }); e != nil {
+		return observed, e
 	}
 
-	return nil
+	if e := ce.stage(ctx, cmd, StageHandle, func(ctx context.Context) error { return handler.HandleCommand(ctx, cmd) }); e != nil {
+		return observed, e
+	}
+
+	return observed, nil
 }
 
-func (ce *commandExecuter) executeConstructiveHandler(ctx context.Context, cmd Command, handler Handler) error {
+func (ce *commandExecuter) executeConstructiveHandler(ctx context.Context, repository ReadWriteRepository, cmd Command, handler Handler) (observed func(error) error, err error) {
 
+	var loadedToken ConcurrencyToken
+	var loadedVersion VersionType
 	entity := cmd.Entity()
 	if entity != nil {
 
-		// load aggregate and send it to commandhandler
-		err := ce.repository.Find(entity)
+		// load aggregate and send it to commandhandler, a not found entity is a new one with version 0
+		err = ce.stage(ctx, cmd, StageLoad, func(context.Context) error { return repository.Find(entity) })
+		if err == nil {
+			err = checkTenant(ctx, entity)
+		}
+		if err != nil && !errors.Is(err, ErrEntityNotFound) {
+			return nil, err
+		}
+
+		change := EntityUpdated
 		if err != nil {
-			return err
+			change = EntityCreated
 		}
 
 		// if cmd is versionable check version, entity also should be versionable
-		cmdVersionable, cmdOk := cmd.(Versionable)
-		entityVersionable, eOk := entity.(EntityVersionable)
+		err = ce.stage(ctx, cmd, StageVersionCheck, func(context.Context) error {
+			cmdToken, cmdOk := ConcurrencyTokenOf(cmd)
+			entityToken, eOk := ConcurrencyTokenOf(entity)
 
-		if cmdOk != eOk {
-			return fmt.Errorf("version check fails. cmd.(Versionbale): %v, entity.(Versionable): %v", cmdOk, eOk)
-		}
-		if eOk && cmdOk {
-			if !reflect.DeepEqual(cmdVersionable.Version(), entityVersionable.Version()) {
-				return ErrVersionMismatched
+			if cmdOk != eOk {
+				return fmt.Errorf("version check fails. cmd.(Versionbale): %v, entity.(Versionable): %v", cmdOk, eOk)
+			}
+			if eOk && cmdOk {
+				if !ce.tokenComparer(cmdToken, entityToken) {
+					return ErrVersionMismatched
+				}
 			}
+			loadedToken = entityToken
+			if v, ok := entity.(Versionable); ok {
+				loadedVersion = v.Version()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if e := validateAgainst(cmd, entity); e != nil {
+			return nil, e
 		}
+
+		observed = ce.observeEntity(ctx, cmd, entity, change)
+		ctx = withEntity(ctx, entity)
 	}
 
-	if e := handler.HandleCommand(ctx, cmd); e != nil {
-		return e
+	if e := ce.stage(ctx, cmd, StageHandle, func(ctx context.Context) error { return handler.HandleCommand(ctx, cmd) }); e != nil {
+		return observed, e
 	}
 
-	if e := ce.store.Save(cmd, ce.repository); e != nil {
-		return e
+	if e := ce.stage(ctx, cmd, StageStoreSave, func(ctx context.Context) error { return ce.saveCommand(ctx, cmd, repository) }); e != nil {
+		return observed, e
 	}
 
 	if entity != nil {
-		if entityVersionable, ok := entity.(EntityVersionable); ok {
-			entityVersionable.IncrementVersion()
-
+		err := ce.stage(ctx, cmd, StageRepositorySave, func(context.Context) error {
+			return ce.saveEntity(repository, entity, loadedToken, loadedVersion)
+		})
+		if err != nil {
+			return observed, err
 		}
+	}
 
-		if e := ce.repository.Save(entity); e != nil {
-			return e
+	return observed, nil
+}
+
+// saveCommand saves the command, with its tenant if the store is tenant aware
+func (ce *commandExecuter) saveCommand(ctx context.Context, cmd Command, repository WriteRepository) error {
+	if ts, ok := ce.store.(TenantAwareStore); ok {
+		if tenant, ok := TenantFromContext(ctx); ok {
+			return ts.SaveForTenant(tenant, cmd, repository)
 		}
 	}
 
-	return nil
+	return ce.store.Save(cmd, repository)
 }
 
-func (ce *commandExecuter) publishEvents(ctx context.Context, cmd Command) error {
-	if c, ok := cmd.(WithEvents); ok {
-		events := c.Events(ctx)
-		for _, ev := range events {
-			if err := ce.bus.Publish(ctx, ev); err != nil {
-				return err
+// saveEntity saves the entity, the new version is assigned either by the executer or by the repository
+func (ce *commandExecuter) saveEntity(repository ReadWriteRepository, entity Entity, loadedToken ConcurrencyToken, loadedVersion VersionType) error {
+	if ce.repositoryVersions {
+		if _, ok := ConcurrencyTokenOf(entity); ok {
+			if r, ok := repository.(VersionAssigningRepository); ok {
+				return r.SaveAssignVersion(entity, loadedToken, ce.tokenComparer)
 			}
+			return errRepositoryNotAssigningVersions
 		}
+
+		return repository.Save(entity)
+	}
+
+	if entityVersionable, ok := entity.(EntityVersionable); ok {
+		return saveNextVersion(entityVersionable, func(next Entity) error {
+			// prefer the storage layer to enforce the version check atomically, against the loaded version
+			// whatever the handler did to the entity
+			if r, ok := repository.(ConditionalWriteRepository); ok {
+				return r.SaveIfVersion(next, loadedVersion)
+			}
+			return repository.Save(next)
+		})
 	}
 
+	return repository.Save(entity)
+}
+
+// saveNextVersion saves a copy of the entity with the next version, the entity is only given the next version
+// once it is saved. Entities which are not pointers to structs are incremented before they are saved
+func saveNextVersion(entity EntityVersionable, save func(Entity) error) error {
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		entity.IncrementVersion()
+		return save(entity)
+	}
+
+	next := reflect.New(v.Elem().Type())
+	next.Elem().Set(v.Elem())
+	next.Interface().(EntityVersionable).IncrementVersion()
+
+	if err := save(next.Interface().(Entity)); err != nil {
+		return err
+	}
+	v.Elem().Set(next.Elem())
 	return nil
 }
+
+// publishEvents publishes the events of the command, stamped with the metadata and the tenant of the context
+// and enriched by the event enrichers
+func (ce *commandExecuter) publishEvents(ctx context.Context, cmd Command) (goevent.Events, error) {
+	c, ok := cmd.(WithEvents)
+	if !ok {
+		return nil, nil
+	}
+
+	events := c.Events(ctx)
+	metadata := MetadataFromContext(ctx)
+	tenant, hasTenant := TenantFromContext(ctx)
+	for i, ev := range events {
+		for k, v := range metadata {
+			ev = EventWithMetadata(ev, k, v)
+		}
+		if hasTenant {
+			ev = EventWithMetadata(ev, MetadataTenantID, string(tenant))
+		}
+
+		err := ce.stage(ctx, cmd, StagePublish, func(ctx context.Context) error {
+			for _, enrich := range ce.eventEnrichers {
+				ev = enrich(ctx, cmd, ev)
+			}
+			events[i] = ev
+			return ce.bus.Publish(ctx, ev)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
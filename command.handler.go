@@ -2,7 +2,8 @@ package command
 
 import "context"
 
-// Handler interface that all command handler should implement
+// Handler interface that all command handler should implement. A handler which is not a DestructiveHandler
+// is constructive, it also handles the commands of entities which do not exist yet, see Executer
 type Handler interface {
 
 	// HandleCommand handle the command
@@ -0,0 +1,74 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrCommandNotRegistered command factory not registered
+var ErrCommandNotRegistered = errors.New("Command not registered")
+var errEmptyCommandFactory = errors.New("Can not register a nil command factory")
+var errRegisterDuplicateCommandFactory = func(cmdType Type) error {
+	return fmt.Errorf("Attempt to register duplicate command factory for type %s", cmdType)
+}
+
+var errUnregisterNotRegisteredCommandFactory = func(cmdType Type) error {
+	return fmt.Errorf("Can not un-register not registered command factory for type %s", cmdType)
+}
+
+var factoryMu sync.RWMutex
+var factories = make(map[Type]func() Command)
+
+// RegisterCommand register a factory creating empty commands of the type, transports use it to decode commands.
+// The factory returns a pointer the command can be decoded into
+func RegisterCommand(cmdType Type, factory func() Command) error {
+	if cmdType == Type("") {
+		return errEmptyCommandType
+	}
+	if factory == nil {
+		return errEmptyCommandFactory
+	}
+
+	factoryMu.Lock()
+	defer factoryMu.Unlock()
+
+	if _, ok := factories[cmdType]; ok {
+		return errRegisterDuplicateCommandFactory(cmdType)
+	}
+	factories[cmdType] = factory
+	return nil
+}
+
+// UnRegisterCommand un register a command factory
+func UnRegisterCommand(cmdType Type) error {
+	if cmdType == Type("") {
+		return errEmptyCommandType
+	}
+
+	factoryMu.Lock()
+	defer factoryMu.Unlock()
+
+	if _, ok := factories[cmdType]; !ok {
+		return errUnregisterNotRegisteredCommandFactory(cmdType)
+	}
+	delete(factories, cmdType)
+	return nil
+}
+
+// CreateCommand creates an empty command of the type with its registered factory
+func CreateCommand(cmdType Type) (Command, error) {
+	factoryMu.RLock()
+	factory, ok := factories[cmdType]
+	factoryMu.RUnlock()
+
+	if !ok {
+		return nil, ErrCommandNotRegistered
+	}
+
+	cmd := factory()
+	if cmd == nil {
+		return nil, errNilCommandHandler
+	}
+	return cmd, nil
+}
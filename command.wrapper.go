@@ -0,0 +1,19 @@
+package command
+
+// Wrapper is a command wrapping another one, e.g. to add interceptor or validation methods to it
+type Wrapper interface {
+	Command
+	// Unwrap returns the wrapped command
+	Unwrap() Command
+}
+
+// UnwrapCommand returns the innermost command of the wrappers
+func UnwrapCommand(cmd Command) Command {
+	for {
+		w, ok := cmd.(Wrapper)
+		if !ok {
+			return cmd
+		}
+		cmd = w.Unwrap()
+	}
+}
@@ -1,6 +1,8 @@
 package command
 
 import (
+	"time"
+
 	"github.com/gapsquare/goevent"
 )
 
@@ -12,6 +14,7 @@ type Configuration func(*configureOption)
 func BuildConfiguration(options ...Configuration) configureOption {
 	cfg := configureOption{
 		//eventStore:
+		tokenComparer: DefaultTokenComparer,
 	}
 
 	for _, option := range options {
@@ -25,6 +28,22 @@ type configureOption struct {
 	eventStore   goevent.EventStore
 	commandStore Store
 	eventBus     goevent.EventBus
+
+	tokenComparer      TokenComparer
+	repositoryVersions bool
+
+	afterSave    []AfterSaveInterceptor
+	afterPublish []AfterPublishInterceptor
+
+	tenantIsolation bool
+
+	executionTimeout time.Duration
+
+	observers []Observer
+
+	eventEnrichers []EventEnricher
+
+	entityObservers []EntityObserver
 }
 
 // WithEventStore sets specific EventStore
@@ -47,3 +66,68 @@ func WithEventBus(eBus goevent.EventBus) Configuration {
 		c.eventBus = eBus
 	}
 }
+
+// WithTokenComparer sets specific TokenComparer used by the version check
+func WithTokenComparer(comparer TokenComparer) Configuration {
+	return func(c *configureOption) {
+		c.tokenComparer = comparer
+	}
+}
+
+// WithRepositoryAssignedVersions lets the repository, instead of the executer, assign the new version on save.
+// The repository, its tenant partitions and its transactions must implement VersionAssigningRepository,
+// the TokenComparer is passed to the repository
+func WithRepositoryAssignedVersions() Configuration {
+	return func(c *configureOption) {
+		c.repositoryVersions = true
+	}
+}
+
+// WithAfterSaveInterceptor adds interceptors called after the entity is saved
+func WithAfterSaveInterceptor(interceptors ...AfterSaveInterceptor) Configuration {
+	return func(c *configureOption) {
+		c.afterSave = append(c.afterSave, interceptors...)
+	}
+}
+
+// WithAfterPublishInterceptor adds interceptors called after the events are published
+func WithAfterPublishInterceptor(interceptors ...AfterPublishInterceptor) Configuration {
+	return func(c *configureOption) {
+		c.afterPublish = append(c.afterPublish, interceptors...)
+	}
+}
+
+// WithTenantIsolation rejects commands executed without a tenant in the context, see WithTenant
+func WithTenantIsolation() Configuration {
+	return func(c *configureOption) {
+		c.tenantIsolation = true
+	}
+}
+
+// WithExecutionTimeout sets the maximum duration of an execution, the deadline of the context is used if it is earlier
+func WithExecutionTimeout(d time.Duration) Configuration {
+	return func(c *configureOption) {
+		c.executionTimeout = d
+	}
+}
+
+// WithObserver adds observers of the executions and their stages
+func WithObserver(observers ...Observer) Configuration {
+	return func(c *configureOption) {
+		c.observers = append(c.observers, observers...)
+	}
+}
+
+// WithEventEnricher adds enrichers of the published events, called in order
+func WithEventEnricher(enrichers ...EventEnricher) Configuration {
+	return func(c *configureOption) {
+		c.eventEnrichers = append(c.eventEnrichers, enrichers...)
+	}
+}
+
+// WithEntityObserver adds observers of the entities changed by the commands
+func WithEntityObserver(observers ...EntityObserver) Configuration {
+	return func(c *configureOption) {
+		c.entityObservers = append(c.entityObservers, observers...)
+	}
+}
@@ -0,0 +1,118 @@
+package deadletter
+
+import (
+	"context"
+	"time"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/command/bus"
+)
+
+// Option configures a Manager
+type Option func(*Manager)
+
+// WithClaimTimeout sets the duration after which the claim of a re-drive which did not complete, e.g. because
+// its process stopped, expires and the record can be re-driven again, default 5 minutes
+func WithClaimTimeout(d time.Duration) Option {
+	return func(m *Manager) {
+		m.claimTimeout = d
+	}
+}
+
+// WithClock sets the function returning the current time, useful in tests
+func WithClock(now func() time.Time) Option {
+	return func(m *Manager) {
+		m.now = now
+	}
+}
+
+// Manager captures the commands failing permanently and lets operators list, inspect, re-drive or discard them
+type Manager struct {
+	store        Store
+	executer     command.Executer
+	claimTimeout time.Duration
+	now          func() time.Time
+}
+
+var _ = bus.DeadLetterSink(&Manager{})
+
+// NewManager creates a Manager storing the dead letters in the store and re-driving them with the executer
+func NewManager(store Store, executer command.Executer, options ...Option) *Manager {
+	m := &Manager{store: store, executer: executer, claimTimeout: 5 * time.Minute, now: time.Now}
+	for _, option := range options {
+		option(m)
+	}
+	return m
+}
+
+// Capture dead-letters an encoded command which failed attempts times, with the error of its last attempt
+func (m *Manager) Capture(ctx context.Context, id string, envelope command.Envelope, attempts int, err error) error {
+	now := m.now()
+	return m.store.Save(ctx, Record{
+		ID:             id,
+		Envelope:       envelope,
+		Error:          err.Error(),
+		Attempts:       attempts,
+		DeadLetteredAt: now,
+		UpdatedAt:      now,
+	})
+}
+
+// DeadLetter implements the bus.DeadLetterSink interface, the record has the ID of the message
+func (m *Manager) DeadLetter(ctx context.Context, msg bus.Message, err error) error {
+	return m.Capture(ctx, msg.ID, msg.Envelope, msg.Attempt, err)
+}
+
+// List returns the dead letters matching the filter, oldest first
+func (m *Manager) List(ctx context.Context, f Filter) ([]Record, error) {
+	return m.store.List(ctx, f)
+}
+
+// Inspect returns a dead letter
+func (m *Manager) Inspect(ctx context.Context, id string) (Record, error) {
+	return m.store.Find(ctx, id)
+}
+
+// Redrive executes a dead letter again with the metadata it was sent with. The record is claimed first,
+// ErrConflict is returned if it is re-driven concurrently. It is removed if the execution succeeds,
+// otherwise its error is updated and the error of the execution is returned
+func (m *Manager) Redrive(ctx context.Context, id string) error {
+	r, err := m.store.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	now := m.now()
+	if !r.ClaimedAt.IsZero() && now.Sub(r.ClaimedAt) < m.claimTimeout {
+		return ErrConflict
+	}
+	claimed := r
+	claimed.Attempts++
+	claimed.UpdatedAt = now
+	claimed.ClaimedAt = now
+	if err := m.store.CompareAndSwap(ctx, r, claimed); err != nil {
+		return err
+	}
+
+	cmd, err := claimed.Envelope.Command()
+	if err == nil {
+		err = m.executer.Execute(claimed.Envelope.Context(ctx), cmd)
+	}
+	if err == nil {
+		return m.store.Remove(ctx, id)
+	}
+
+	failed := claimed
+	failed.Error = err.Error()
+	failed.UpdatedAt = m.now()
+	failed.ClaimedAt = time.Time{}
+	if e := m.store.CompareAndSwap(ctx, claimed, failed); e != nil {
+		return e
+	}
+	return err
+}
+
+// Discard removes a dead letter without executing it
+func (m *Manager) Discard(ctx context.Context, id string) error {
+	return m.store.Remove(ctx, id)
+}
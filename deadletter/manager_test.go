@@ -0,0 +1,224 @@
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/command/bus"
+
+	"github.com/gapsquare/command/mocks"
+)
+
+const (
+	chargeType = command.Type("deadletter.charge")
+	refundType = command.Type("deadletter.refund")
+)
+
+type chargeCommand struct {
+	ID     int          `json:"id"`
+	Amount int          `json:"amount"`
+	Type   command.Type `json:"-"`
+}
+
+func (c *chargeCommand) CommandType() command.Type { return c.Type }
+func (c *chargeCommand) Entity() command.Entity    { return &mocks.SimpleModel{ID: c.ID} }
+
+func init() {
+	command.RegisterCommand(chargeType, func() command.Command { return &chargeCommand{Type: chargeType} })
+	command.RegisterCommand(refundType, func() command.Command { return &chargeCommand{Type: refundType} })
+}
+
+type executer struct {
+	err      error
+	executed []command.Command
+	tenant   command.TenantID
+}
+
+func (e *executer) Execute(ctx context.Context, cmd command.Command) error {
+	e.tenant, _ = command.TenantFromContext(ctx)
+	if e.err != nil {
+		return e.err
+	}
+	e.executed = append(e.executed, cmd)
+	return nil
+}
+
+func envelope(t *testing.T, ctx context.Context, cmd command.Command) command.Envelope {
+	env, err := command.NewEnvelope(ctx, cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return env
+}
+
+func TestManager_ListInspectDiscard(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := NewManager(NewMemoryStore(), &executer{}, WithClock(func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}))
+	ctx := context.Background()
+
+	errCharge := errors.New("can not charge")
+	m.Capture(ctx, "1", envelope(t, ctx, &chargeCommand{ID: 1, Type: chargeType}), 3, errCharge)
+	m.Capture(ctx, "2", envelope(t, ctx, &chargeCommand{ID: 2, Type: refundType}), 3, errCharge)
+	m.Capture(ctx, "3", envelope(t, ctx, &chargeCommand{ID: 3, Type: chargeType}), 3, errCharge)
+
+	records, err := m.List(ctx, Filter{CommandType: chargeType})
+	if err != nil || len(records) != 2 || records[0].ID != "1" || records[1].ID != "3" {
+		t.Error("the records of the type should be listed oldest first:", records, err)
+	}
+	if records, _ := m.List(ctx, Filter{Limit: 1}); len(records) != 1 {
+		t.Error("the records should be limited:", records)
+	}
+
+	r, err := m.Inspect(ctx, "2")
+	if err != nil || r.Error != errCharge.Error() || r.Attempts != 3 || r.Envelope.Type != refundType || r.DeadLetteredAt.IsZero() {
+		t.Error("the record should be inspected:", r, err)
+	}
+
+	if err := m.Discard(ctx, "2"); err != nil {
+		t.Error("there should be no error:", err)
+	}
+	if _, err := m.Inspect(ctx, "2"); err != ErrNotFound {
+		t.Error("the record should be discarded:", err)
+	}
+	if err := m.Discard(ctx, "2"); err != ErrNotFound {
+		t.Error("there should be a not found error:", err)
+	}
+}
+
+func TestManager_Redrive(t *testing.T) {
+	e := &executer{err: errors.New("still failing")}
+	m := NewManager(NewMemoryStore(), e)
+	ctx := command.WithTenant(context.Background(), "acme")
+
+	m.Capture(ctx, "1", envelope(t, ctx, &chargeCommand{ID: 1, Amount: 10, Type: chargeType}), 3, errors.New("can not charge"))
+
+	if err := m.Redrive(ctx, "1"); err != e.err {
+		t.Error("the execution error should be returned:", err)
+	}
+	if r, _ := m.Inspect(ctx, "1"); r.Attempts != 4 || r.Error != e.err.Error() {
+		t.Error("the record should be updated:", r)
+	}
+
+	e.err = nil
+	if err := m.Redrive(context.Background(), "1"); err != nil {
+		t.Error("there should be no error:", err)
+	}
+	if len(e.executed) != 1 || e.executed[0].(*chargeCommand).Amount != 10 || e.tenant != "acme" {
+		t.Error("the command should be executed with its tenant:", e.executed, e.tenant)
+	}
+	if _, err := m.Inspect(ctx, "1"); err != ErrNotFound {
+		t.Error("the re-driven record should be removed:", err)
+	}
+	if err := m.Redrive(ctx, "1"); err != ErrNotFound {
+		t.Error("there should be a not found error:", err)
+	}
+}
+
+// blockingExecuter executes the commands when they are released
+type blockingExecuter struct {
+	executions int32
+	started    chan struct{}
+	release    chan struct{}
+}
+
+func (e *blockingExecuter) Execute(context.Context, command.Command) error {
+	atomic.AddInt32(&e.executions, 1)
+	e.started <- struct{}{}
+	<-e.release
+	return errors.New("still failing")
+}
+
+func TestManager_ConcurrentRedrive(t *testing.T) {
+	now := time.Unix(0, 0)
+	e := &blockingExecuter{started: make(chan struct{}, 10), release: make(chan struct{})}
+	m := NewManager(NewMemoryStore(), e, WithClaimTimeout(time.Minute), WithClock(func() time.Time { return now }))
+	ctx := context.Background()
+	m.Capture(ctx, "1", envelope(t, ctx, &chargeCommand{ID: 1, Type: chargeType}), 3, errors.New("can not charge"))
+
+	const n = 10
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- m.Redrive(ctx, "1")
+		}()
+	}
+	<-e.started
+	// the losers return without executing the command
+	for i := 0; i < n-1; i++ {
+		if err := <-errs; err != ErrConflict {
+			t.Error("the concurrent re-drives should conflict:", err)
+		}
+	}
+	close(e.release)
+	wg.Wait()
+
+	if executions := atomic.LoadInt32(&e.executions); executions != 1 {
+		t.Error("the command should be executed once:", executions)
+	}
+	r, _ := m.Inspect(ctx, "1")
+	if r.Attempts != 4 || !r.ClaimedAt.IsZero() {
+		t.Error("the record should be released after the failed re-drive:", r)
+	}
+}
+
+func TestManager_RedriveClaimExpires(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryStore()
+	m := NewManager(store, &executer{}, WithClaimTimeout(time.Minute), WithClock(func() time.Time { return now }))
+	ctx := context.Background()
+	m.Capture(ctx, "1", envelope(t, ctx, &chargeCommand{ID: 1, Type: chargeType}), 3, errors.New("can not charge"))
+
+	// a re-drive claimed the record and stopped before it completed
+	r, _ := store.Find(ctx, "1")
+	claimed := r
+	claimed.ClaimedAt = now
+	store.Save(ctx, claimed)
+
+	if err := m.Redrive(ctx, "1"); err != ErrConflict {
+		t.Error("the claimed record should not be re-driven:", err)
+	}
+	if err := store.CompareAndSwap(ctx, r, r); err != nil {
+		t.Error("there should be no error:", err)
+	}
+	if err := store.CompareAndSwap(ctx, Record{ID: "1", Attempts: 1}, r); err != ErrConflict {
+		t.Error("a changed record should not be swapped:", err)
+	}
+
+	store.Save(ctx, claimed)
+	now = now.Add(time.Minute)
+	if err := m.Redrive(ctx, "1"); err != nil {
+		t.Error("the expired claim should be re-driven:", err)
+	}
+}
+
+func TestManager_BusSink(t *testing.T) {
+	transport := bus.NewChannelTransport(1)
+	m := NewManager(NewMemoryStore(), &executer{})
+	consumer := bus.NewConsumer(transport, &executer{err: errors.New("can not charge")},
+		bus.WithMaxAttempts(1), bus.WithDeadLetterSink(m))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	deliveries, _ := transport.Subscribe(ctx)
+
+	bus.NewCommandBus(transport).Send(ctx, &chargeCommand{ID: 1, Type: chargeType})
+	d := <-deliveries
+	if err := consumer.Handle(ctx, d); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	if r, err := m.Inspect(ctx, d.Message().ID); err != nil || r.Attempts != 1 {
+		t.Error("the message should be dead-lettered:", r, err)
+	}
+}
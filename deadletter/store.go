@@ -0,0 +1,143 @@
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gapsquare/command"
+)
+
+// ErrNotFound error when a dead letter does not exist
+var ErrNotFound = errors.New("Dead letter not found")
+
+// ErrConflict error when a dead letter changed since it was read, e.g. it is re-driven concurrently
+var ErrConflict = errors.New("Dead letter changed concurrently")
+
+// Record is a dead-lettered command
+type Record struct {
+	ID string `json:"id"`
+	// Envelope is the encoded command with its metadata
+	Envelope command.Envelope `json:"envelope"`
+	// Error is the error of the last attempt
+	Error    string `json:"error"`
+	Attempts int    `json:"attempts"`
+	// DeadLetteredAt is the time the command was dead-lettered, UpdatedAt the time of its last change
+	DeadLetteredAt time.Time `json:"dead_lettered_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	// ClaimedAt is the start of the running re-drive, zero when the record is not re-driven
+	ClaimedAt time.Time `json:"claimed_at"`
+}
+
+// Filter filters the listed records, its zero value lists all records
+type Filter struct {
+	CommandType command.Type
+	// Limit is the maximum number of records, zero is unlimited
+	Limit int
+}
+
+func (f Filter) match(r Record) bool {
+	return f.CommandType == "" || r.Envelope.Type == f.CommandType
+}
+
+// Store stores the dead letters
+type Store interface {
+	// Save saves a record, it replaces the record with the same ID
+	Save(context.Context, Record) error
+	// CompareAndSwap atomically replaces the record old by r if its stored Attempts and UpdatedAt
+	// are still those of old, ErrConflict otherwise and ErrNotFound if it does not exist
+	CompareAndSwap(ctx context.Context, old, r Record) error
+	// Find returns a record, ErrNotFound if it does not exist
+	Find(ctx context.Context, id string) (Record, error)
+	// List returns the records matching the filter, oldest first
+	List(context.Context, Filter) ([]Record, error)
+	// Remove removes a record, ErrNotFound if it does not exist
+	Remove(ctx context.Context, id string) error
+}
+
+// MemoryStore is an in-memory Store
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]Record
+}
+
+var _ = Store(&MemoryStore{})
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+// Save implements the Store interface
+func (s *MemoryStore) Save(_ context.Context, r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[r.ID] = r
+	return nil
+}
+
+// CompareAndSwap implements the Store interface
+func (s *MemoryStore) CompareAndSwap(_ context.Context, old, r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.records[old.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Attempts != old.Attempts || !stored.UpdatedAt.Equal(old.UpdatedAt) {
+		return ErrConflict
+	}
+	s.records[r.ID] = r
+	return nil
+}
+
+// Find implements the Store interface
+func (s *MemoryStore) Find(_ context.Context, id string) (Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.records[id]
+	if !ok {
+		return Record{}, ErrNotFound
+	}
+	return r, nil
+}
+
+// List implements the Store interface
+func (s *MemoryStore) List(_ context.Context, f Filter) ([]Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var records []Record
+	for _, r := range s.records {
+		if f.match(r) {
+			records = append(records, r)
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].DeadLetteredAt.Equal(records[j].DeadLetteredAt) {
+			return records[i].ID < records[j].ID
+		}
+		return records[i].DeadLetteredAt.Before(records[j].DeadLetteredAt)
+	})
+	if f.Limit > 0 && len(records) > f.Limit {
+		records = records[:f.Limit]
+	}
+	return records, nil
+}
+
+// Remove implements the Store interface
+func (s *MemoryStore) Remove(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.records, id)
+	return nil
+}
@@ -0,0 +1,18 @@
+package command
+
+import "context"
+
+type entityContextKey int
+
+const entityKey entityContextKey = iota
+
+// EntityFromContext returns the entity loaded by the executer for the command being handled,
+// it allows handler middlewares to see the stored state instead of the entity built by the command
+func EntityFromContext(ctx context.Context) (Entity, bool) {
+	entity, ok := ctx.Value(entityKey).(Entity)
+	return entity, ok
+}
+
+func withEntity(ctx context.Context, entity Entity) context.Context {
+	return context.WithValue(ctx, entityKey, entity)
+}
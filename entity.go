@@ -1,7 +1,92 @@
 package command
 
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidUUID error for a malformed UUID entity id
+var ErrInvalidUUID = errors.New("Invalid UUID")
+
+// compositeSeparator separates the parts of a composite EntityID
+const compositeSeparator = ":"
+
+var compositeEscaper = strings.NewReplacer("%", "%25", compositeSeparator, "%3A")
+var compositeUnescaper = strings.NewReplacer("%3A", compositeSeparator, "%25", "%")
+
 // EntityID uniqueidentifier type for aggregates
-type EntityID int
+type EntityID string
+
+// String implements fmt.Stringer interface
+func (id EntityID) String() string {
+	return string(id)
+}
+
+// IsEmpty returns true if the id is not set
+func (id EntityID) IsEmpty() bool {
+	return id == ""
+}
+
+// Parts returns the parts of a composite id, a non composite id has a single part
+func (id EntityID) Parts() []string {
+	parts := strings.Split(string(id), compositeSeparator)
+	for i, p := range parts {
+		parts[i] = compositeUnescaper.Replace(p)
+	}
+	return parts
+}
+
+// IntEntityID creates an EntityID from an integer
+func IntEntityID(id int) EntityID {
+	return EntityID(strconv.Itoa(id))
+}
+
+// CompositeEntityID creates an EntityID from several parts, parts can be read back with Parts
+func CompositeEntityID(parts ...string) EntityID {
+	escaped := make([]string, len(parts))
+	for i, p := range parts {
+		escaped[i] = compositeEscaper.Replace(p)
+	}
+	return EntityID(strings.Join(escaped, compositeSeparator))
+}
+
+// NewUUIDEntityID creates an EntityID from a new random (version 4) UUID
+func NewUUIDEntityID() EntityID {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		panic(fmt.Sprintf("can not generate UUID: %v", err))
+	}
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+
+	return EntityID(fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16]))
+}
+
+// ParseUUIDEntityID creates an EntityID from a UUID string, the id is stored in lower case canonical form
+func ParseUUIDEntityID(s string) (EntityID, error) {
+	if len(s) != 36 {
+		return "", ErrInvalidUUID
+	}
+
+	s = strings.ToLower(s)
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return "", ErrInvalidUUID
+			}
+		default:
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+				return "", ErrInvalidUUID
+			}
+		}
+	}
+
+	return EntityID(s), nil
+}
 
 // Entity is an item which is identified by ID
 type Entity interface {
@@ -0,0 +1,55 @@
+package command
+
+import "context"
+
+// EntityChange is the kind of change of an entity by a command
+type EntityChange int
+
+// Kinds of entity changes
+const (
+	// EntityCreated the entity was not found and is saved by a constructive command
+	EntityCreated EntityChange = iota
+	// EntityUpdated the entity was found and is saved by a constructive command
+	EntityUpdated
+	// EntityRemoved the entity is removed by a destructive command
+	EntityRemoved
+)
+
+// String returns the name of the change
+func (c EntityChange) String() string {
+	switch c {
+	case EntityCreated:
+		return "created"
+	case EntityUpdated:
+		return "updated"
+	case EntityRemoved:
+		return "removed"
+	}
+	return "unknown"
+}
+
+// EntityObserver observes the entity of a command around its handling, e.g. to audit its changes
+type EntityObserver interface {
+	// ObserveEntity is called with the loaded entity before the command is handled. The returned function is
+	// called with the result once the transaction of the execution is committed, or rolled back, so an error
+	// means the change did not happen. The entity then has its new state, an error returned for a successful
+	// execution is returned by Execute but the change stays committed
+	ObserveEntity(ctx context.Context, cmd Command, entity Entity, change EntityChange) func(error) error
+}
+
+// observeEntity calls the entity observers of the executer
+func (ce *commandExecuter) observeEntity(ctx context.Context, cmd Command, entity Entity, change EntityChange) func(error) error {
+	finishes := make([]func(error) error, len(ce.entityObservers))
+	for i, o := range ce.entityObservers {
+		finishes[i] = o.ObserveEntity(ctx, cmd, entity, change)
+	}
+
+	return func(err error) error {
+		for i := len(finishes) - 1; i >= 0; i-- {
+			if e := finishes[i](err); e != nil && err == nil {
+				err = e
+			}
+		}
+		return err
+	}
+}
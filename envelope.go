@@ -0,0 +1,67 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Envelope is a command encoded to be sent to another process, e.g. by a transport or a command bus,
+// or to be stored. The command is created from its type with the factory registered with RegisterCommand
+type Envelope struct {
+	Type Type `json:"type"`
+	// Payload is the json encoding of the command
+	Payload json.RawMessage `json:"payload"`
+	// Metadata is the metadata of the context the command is sent with, including its tenant
+	Metadata Metadata `json:"metadata,omitempty"`
+}
+
+// NewEnvelope encodes the command with the metadata and the tenant of the context
+func NewEnvelope(ctx context.Context, cmd Command) (Envelope, error) {
+	payload, err := json.Marshal(cmd)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	var metadata Metadata
+	if m := MetadataFromContext(ctx); len(m) > 0 {
+		metadata = Metadata{}
+		for k, v := range m {
+			metadata[k] = v
+		}
+	}
+	if tenant, ok := TenantFromContext(ctx); ok {
+		if metadata == nil {
+			metadata = Metadata{}
+		}
+		metadata[MetadataTenantID] = string(tenant)
+	}
+
+	return Envelope{Type: cmd.CommandType(), Payload: payload, Metadata: metadata}, nil
+}
+
+// Command decodes the command of the envelope
+func (e Envelope) Command() (Command, error) {
+	cmd, err := CreateCommand(e.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(e.Payload) > 0 {
+		if err := json.Unmarshal(e.Payload, cmd); err != nil {
+			return nil, err
+		}
+	}
+	return cmd, nil
+}
+
+// Context returns ctx carrying the metadata and the tenant of the envelope
+func (e Envelope) Context(ctx context.Context) context.Context {
+	for k, v := range e.Metadata {
+		if k == MetadataTenantID {
+			ctx = WithTenant(ctx, TenantID(v))
+			continue
+		}
+		ctx = WithMetadata(ctx, k, v)
+	}
+	return ctx
+}
@@ -0,0 +1,112 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gapsquare/goevent"
+)
+
+// MetadataEvent is an event carrying metadata stamped by the executer, e.g. its tenant.
+// The metadata only lives in process, events published to another process must be encoded
+// in an EventEnvelope to keep it
+type MetadataEvent interface {
+	goevent.Event
+	Metadata() map[string]string
+}
+
+// EventEnricher returns the event to publish, e.g. with additional metadata. It is called by the executer
+// with the context of the publish stage, after the metadata and the tenant of the context are stamped
+type EventEnricher func(context.Context, Command, goevent.Event) goevent.Event
+
+type metadataEvent struct {
+	goevent.Event
+	metadata map[string]string
+}
+
+func (e metadataEvent) Metadata() map[string]string { return e.metadata }
+
+// Unwrap returns the event the metadata is stamped on
+func (e metadataEvent) Unwrap() goevent.Event { return e.Event }
+
+// UnwrapEvent returns the event published by the command, without the metadata stamped on it
+func UnwrapEvent(ev goevent.Event) goevent.Event {
+	if e, ok := ev.(metadataEvent); ok {
+		return e.Event
+	}
+	return ev
+}
+
+// EventWithMetadata returns the event with the metadata key set to value, the original event is not modified
+func EventWithMetadata(ev goevent.Event, key, value string) goevent.Event {
+	metadata := map[string]string{}
+	for k, v := range EventMetadata(ev) {
+		metadata[k] = v
+	}
+	metadata[key] = value
+
+	return metadataEvent{Event: UnwrapEvent(ev), metadata: metadata}
+}
+
+// EventMetadata returns the metadata of an event, nil if it has none
+func EventMetadata(ev goevent.Event) map[string]string {
+	if e, ok := ev.(MetadataEvent); ok {
+		return e.Metadata()
+	}
+	return nil
+}
+
+// EventEnvelope is an event encoded with its metadata to be published to another process, e.g. by a
+// serializing event bus. The data is created from its topic with the factory registered with
+// goevent.RegisterEventData
+type EventEnvelope struct {
+	Topic     goevent.EventTopic  `json:"topic"`
+	Data      json.RawMessage     `json:"data,omitempty"`
+	Timestamp time.Time           `json:"timestamp"`
+	Version   goevent.VersionType `json:"version"`
+	Metadata  map[string]string   `json:"metadata,omitempty"`
+}
+
+// NewEventEnvelope encodes the event with its metadata
+func NewEventEnvelope(ev goevent.Event) (EventEnvelope, error) {
+	envelope := EventEnvelope{
+		Topic:     ev.Topic(),
+		Timestamp: ev.Timestamp(),
+		Version:   ev.Version(),
+		Metadata:  EventMetadata(ev),
+	}
+
+	if data := ev.Data(); data != nil {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return EventEnvelope{}, err
+		}
+		envelope.Data = payload
+	}
+	return envelope, nil
+}
+
+// Event decodes the event of the envelope, its metadata is stamped on it again
+func (e EventEnvelope) Event() (goevent.Event, error) {
+	var data goevent.EventData
+	if len(e.Data) > 0 && string(e.Data) != "null" {
+		var err error
+		if data, err = goevent.CreateEventData(e.Topic); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(e.Data, data); err != nil {
+			return nil, err
+		}
+	}
+
+	ev := goevent.NewEventTimeVersion(e.Topic, data, e.Timestamp, e.Version)
+	if len(e.Metadata) > 0 {
+		metadata := map[string]string{}
+		for k, v := range e.Metadata {
+			metadata[k] = v
+		}
+		ev = metadataEvent{Event: ev, metadata: metadata}
+	}
+	return ev, nil
+}
@@ -0,0 +1,22 @@
+package instrumentation
+
+import (
+	"time"
+
+	"github.com/gapsquare/command"
+)
+
+// StageExecute is the stage of the whole execution, its failures are the failed executions
+const StageExecute = command.Stage("execute")
+
+// Metrics records the metrics of the executions per command type
+type Metrics interface {
+	// IncExecutions counts an execution
+	IncExecutions(cmdType command.Type)
+	// IncFailures counts a failure of the stage, StageExecute counts the failed executions
+	IncFailures(cmdType command.Type, stage command.Stage)
+	// ObserveDuration records the duration of the stage, StageExecute records the duration of the executions
+	ObserveDuration(cmdType command.Type, stage command.Stage, d time.Duration)
+	// IncEventsPublished counts a published event
+	IncEventsPublished(cmdType command.Type)
+}
@@ -0,0 +1,43 @@
+package instrumentation
+
+import (
+	"context"
+	"time"
+
+	"github.com/gapsquare/command"
+)
+
+type observer struct {
+	metrics Metrics
+	now     func() time.Time
+}
+
+var _ = command.Observer(&observer{})
+
+// NewObserver returns an Observer recording the metrics of the executions, see command.WithObserver
+func NewObserver(metrics Metrics) command.Observer {
+	return &observer{metrics: metrics, now: time.Now}
+}
+
+// ObserveExecution implements the command.Observer interface
+func (o *observer) ObserveExecution(ctx context.Context, cmd command.Command) (context.Context, func(error)) {
+	o.metrics.IncExecutions(cmd.CommandType())
+	return ctx, o.finish(cmd.CommandType(), StageExecute)
+}
+
+// ObserveStage implements the command.Observer interface
+func (o *observer) ObserveStage(ctx context.Context, cmd command.Command, stage command.Stage) (context.Context, func(error)) {
+	return ctx, o.finish(cmd.CommandType(), stage)
+}
+
+func (o *observer) finish(cmdType command.Type, stage command.Stage) func(error) {
+	start := o.now()
+	return func(err error) {
+		o.metrics.ObserveDuration(cmdType, stage, o.now().Sub(start))
+		if err != nil {
+			o.metrics.IncFailures(cmdType, stage)
+		} else if stage == command.StagePublish {
+			o.metrics.IncEventsPublished(cmdType)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package instrumentation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/goevent"
+
+	"github.com/gapsquare/command/mocks"
+)
+
+const eventsType = command.Type("instrumentation.events")
+
+type eventsCommand struct {
+	mocks.Command
+	topics []goevent.EventTopic
+}
+
+func (c *eventsCommand) CommandType() command.Type { return eventsType }
+func (c *eventsCommand) Events(ctx context.Context) goevent.Events {
+	var events goevent.Events
+	for _, topic := range c.topics {
+		events = append(events, goevent.NewEvent(topic, nil))
+	}
+	return events
+}
+
+func TestObserver_RecordsStages(t *testing.T) {
+	handler := &mocks.MockCommandHandler{}
+	command.RegisterCommandHandler(eventsType, handler)
+	defer command.UnRegisterCommandHandler(eventsType)
+
+	recorder := NewRecorder()
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(mocks.MockCommandStore{}),
+		command.WithEventBus(&mocks.EventBus{}),
+		command.WithObserver(NewObserver(recorder)),
+	), &mocks.MockRepository{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ce.Execute(context.Background(), &eventsCommand{topics: []goevent.EventTopic{"a", "b"}}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	errHandle := errors.New("can not handle")
+	handler.Err = errHandle
+	if err := ce.Execute(context.Background(), &eventsCommand{}); err != errHandle {
+		t.Fatal("the handler error should be returned:", err)
+	}
+
+	if n := recorder.Executions(eventsType); n != 2 {
+		t.Error("there should be 2 executions:", n)
+	}
+	if n := recorder.EventsPublished(eventsType); n != 2 {
+		t.Error("there should be 2 published events:", n)
+	}
+	if n := recorder.Failures(eventsType, command.StageHandle); n != 1 {
+		t.Error("there should be 1 handle failure:", n)
+	}
+	if n := recorder.Failures(eventsType, StageExecute); n != 1 {
+		t.Error("there should be 1 failed execution:", n)
+	}
+	if n := recorder.Failures(eventsType, command.StageStoreSave); n != 0 {
+		t.Error("there should be no store failure:", n)
+	}
+
+	for stage, n := range map[command.Stage]int{
+		StageExecute:                2,
+		command.StageHandlerLookup:  2,
+		command.StageLoad:           2,
+		command.StageVersionCheck:   2,
+		command.StageHandle:         2,
+		command.StageStoreSave:      1,
+		command.StageRepositorySave: 1,
+		command.StagePublish:        2,
+	} {
+		if d := recorder.Durations(eventsType, stage); len(d) != n {
+			t.Error("unexpected number of durations of stage", stage, len(d))
+		}
+	}
+}
+
+func TestObserver_RecordsHandlerLookupFailure(t *testing.T) {
+	recorder := NewRecorder()
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(mocks.MockCommandStore{}),
+		command.WithEventBus(&mocks.EventBus{}),
+		command.WithObserver(NewObserver(recorder)),
+	), &mocks.MockRepository{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ce.Execute(context.Background(), &eventsCommand{}); err == nil {
+		t.Fatal("there should be an error")
+	}
+	if n := recorder.Failures(eventsType, command.StageHandlerLookup); n != 1 {
+		t.Error("there should be 1 handler lookup failure:", n)
+	}
+}
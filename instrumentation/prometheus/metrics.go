@@ -0,0 +1,74 @@
+package prometheus
+
+import (
+	"time"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/command/instrumentation"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics is an instrumentation.Metrics recording to Prometheus collectors
+type Metrics struct {
+	executions *prom.CounterVec
+	failures   *prom.CounterVec
+	durations  *prom.HistogramVec
+	published  *prom.CounterVec
+}
+
+var _ = instrumentation.Metrics(&Metrics{})
+
+// NewMetrics creates the collectors in the namespace and registers them
+func NewMetrics(registerer prom.Registerer, namespace string) (*Metrics, error) {
+	m := &Metrics{
+		executions: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "command_executions_total",
+			Help:      "Number of command executions.",
+		}, []string{"command_type"}),
+		failures: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "command_failures_total",
+			Help:      "Number of failures by execution stage, the execute stage counts failed executions.",
+		}, []string{"command_type", "stage"}),
+		durations: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "command_stage_duration_seconds",
+			Help:      "Duration of the execution stages, the execute stage is the whole execution.",
+			Buckets:   prom.DefBuckets,
+		}, []string{"command_type", "stage"}),
+		published: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "command_events_published_total",
+			Help:      "Number of events published by commands.",
+		}, []string{"command_type"}),
+	}
+
+	for _, c := range []prom.Collector{m.executions, m.failures, m.durations, m.published} {
+		if err := registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// IncExecutions implements the instrumentation.Metrics interface
+func (m *Metrics) IncExecutions(cmdType command.Type) {
+	m.executions.WithLabelValues(string(cmdType)).Inc()
+}
+
+// IncFailures implements the instrumentation.Metrics interface
+func (m *Metrics) IncFailures(cmdType command.Type, stage command.Stage) {
+	m.failures.WithLabelValues(string(cmdType), string(stage)).Inc()
+}
+
+// ObserveDuration implements the instrumentation.Metrics interface
+func (m *Metrics) ObserveDuration(cmdType command.Type, stage command.Stage, d time.Duration) {
+	m.durations.WithLabelValues(string(cmdType), string(stage)).Observe(d.Seconds())
+}
+
+// IncEventsPublished implements the instrumentation.Metrics interface
+func (m *Metrics) IncEventsPublished(cmdType command.Type) {
+	m.published.WithLabelValues(string(cmdType)).Inc()
+}
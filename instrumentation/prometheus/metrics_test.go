@@ -0,0 +1,42 @@
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gapsquare/command"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := prom.NewRegistry()
+	m, err := NewMetrics(registry, "app")
+	if err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	m.IncExecutions("create")
+	m.IncExecutions("create")
+	m.IncFailures("create", command.StageHandle)
+	m.ObserveDuration("create", command.StageHandle, 10*time.Millisecond)
+	m.IncEventsPublished("create")
+
+	if v := testutil.ToFloat64(m.executions.WithLabelValues("create")); v != 2 {
+		t.Error("there should be 2 executions:", v)
+	}
+	if v := testutil.ToFloat64(m.failures.WithLabelValues("create", "handle")); v != 1 {
+		t.Error("there should be 1 handle failure:", v)
+	}
+	if v := testutil.ToFloat64(m.published.WithLabelValues("create")); v != 1 {
+		t.Error("there should be 1 published event:", v)
+	}
+	if n := testutil.CollectAndCount(registry, "app_command_stage_duration_seconds"); n != 1 {
+		t.Error("there should be 1 duration series:", n)
+	}
+
+	if _, err := NewMetrics(registry, "app"); err == nil {
+		t.Error("registering the collectors twice should fail")
+	}
+}
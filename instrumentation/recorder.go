@@ -0,0 +1,91 @@
+package instrumentation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gapsquare/command"
+)
+
+type stageKey struct {
+	cmdType command.Type
+	stage   command.Stage
+}
+
+// Recorder is an in-memory Metrics, useful in tests
+type Recorder struct {
+	mu         sync.Mutex
+	executions map[command.Type]int
+	failures   map[stageKey]int
+	durations  map[stageKey][]time.Duration
+	published  map[command.Type]int
+}
+
+var _ = Metrics(&Recorder{})
+
+// NewRecorder creates an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		executions: make(map[command.Type]int),
+		failures:   make(map[stageKey]int),
+		durations:  make(map[stageKey][]time.Duration),
+		published:  make(map[command.Type]int),
+	}
+}
+
+// IncExecutions implements the Metrics interface
+func (r *Recorder) IncExecutions(cmdType command.Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.executions[cmdType]++
+}
+
+// IncFailures implements the Metrics interface
+func (r *Recorder) IncFailures(cmdType command.Type, stage command.Stage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures[stageKey{cmdType, stage}]++
+}
+
+// ObserveDuration implements the Metrics interface
+func (r *Recorder) ObserveDuration(cmdType command.Type, stage command.Stage, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := stageKey{cmdType, stage}
+	r.durations[k] = append(r.durations[k], d)
+}
+
+// IncEventsPublished implements the Metrics interface
+func (r *Recorder) IncEventsPublished(cmdType command.Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.published[cmdType]++
+}
+
+// Executions returns the number of executions of the command type
+func (r *Recorder) Executions(cmdType command.Type) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.executions[cmdType]
+}
+
+// Failures returns the number of failures of the stage
+func (r *Recorder) Failures(cmdType command.Type, stage command.Stage) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.failures[stageKey{cmdType, stage}]
+}
+
+// Durations returns the recorded durations of the stage
+func (r *Recorder) Durations(cmdType command.Type, stage command.Stage) []time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]time.Duration(nil), r.durations[stageKey{cmdType, stage}]...)
+}
+
+// EventsPublished returns the number of events published by the command type
+func (r *Recorder) EventsPublished(cmdType command.Type) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.published[cmdType]
+}
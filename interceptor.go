@@ -0,0 +1,14 @@
+package command
+
+import (
+	"context"
+
+	"github.com/gapsquare/goevent"
+)
+
+// AfterSaveInterceptor is called by the executer after the entity is saved, or removed by a destructive
+// handler, and the transaction is committed. An error stops the execution before events are published
+type AfterSaveInterceptor func(context.Context, Command) error
+
+// AfterPublishInterceptor is called by the executer after the events of the command are published
+type AfterPublishInterceptor func(context.Context, Command, goevent.Events) error
@@ -0,0 +1,54 @@
+package command
+
+import (
+	"context"
+
+	"github.com/gapsquare/goevent"
+)
+
+// Metadata keys set by the executer and the transports
+const (
+	MetadataCorrelationID  = "correlation_id"
+	MetadataIdempotencyKey = "idempotency_key"
+)
+
+// Metadata is the metadata of an execution, e.g. its correlation ID. The executer stamps it on the published events
+type Metadata map[string]string
+
+type metadataContextKey int
+
+const metadataKey metadataContextKey = iota
+
+// WithMetadata returns a context carrying the metadata key set to value, the metadata of ctx is not modified
+func WithMetadata(ctx context.Context, key, value string) context.Context {
+	metadata := Metadata{}
+	for k, v := range MetadataFromContext(ctx) {
+		metadata[k] = v
+	}
+	metadata[key] = value
+	return context.WithValue(ctx, metadataKey, metadata)
+}
+
+// MetadataFromContext returns the metadata carried by the context, nil if it has none.
+// The returned metadata must not be modified
+func MetadataFromContext(ctx context.Context) Metadata {
+	metadata, _ := ctx.Value(metadataKey).(Metadata)
+	return metadata
+}
+
+// WithCorrelationID returns a context carrying the correlation ID
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return WithMetadata(ctx, MetadataCorrelationID, id)
+}
+
+// CorrelationID returns the correlation ID carried by the context
+func CorrelationID(ctx context.Context) (string, bool) {
+	id, ok := MetadataFromContext(ctx)[MetadataCorrelationID]
+	return id, ok && id != ""
+}
+
+// EventCorrelationID returns the correlation ID stamped on an event by the executer
+func EventCorrelationID(ev goevent.Event) (string, bool) {
+	id, ok := EventMetadata(ev)[MetadataCorrelationID]
+	return id, ok && id != ""
+}
@@ -0,0 +1,28 @@
+package authz
+
+import (
+	"context"
+
+	"github.com/gapsquare/command"
+)
+
+// NewMiddleware returns a middleware that authorizes commands with the policy before they are handled.
+// The policy receives the entity loaded by the executer, see command.EntityFromContext, or the entity
+// of the command when the handler is not called by an executer
+func NewMiddleware(policy Policy) command.HandlerMiddleware {
+	return command.HandlerMiddleware(func(h command.Handler) command.Handler {
+		return command.HandlerFunc(func(ctx context.Context, cmd command.Command) error {
+			entity, ok := command.EntityFromContext(ctx)
+			if !ok {
+				entity = cmd.Entity()
+			}
+
+			if err := policy.Authorize(ctx, cmd, entity); err != nil {
+				return err
+			}
+
+			// Immediate command execution.
+			return h.HandleCommand(ctx, cmd)
+		})
+	})
+}
@@ -0,0 +1,128 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gapsquare/command"
+
+	"github.com/gapsquare/command/mocks"
+	"github.com/gapsquare/command/repository/memrepo"
+)
+
+func newHandler(inner command.Handler) command.Handler {
+	policy := NewRulePolicy().
+		Allow(mocks.CommandType, AnyOf(RequireRole("admin"), RequireOwner(func(e command.Entity) string {
+			return e.(*mocks.SimpleModel).Content
+		})))
+	return command.UseHandlerMiddleware(inner, NewMiddleware(policy))
+}
+
+func TestMiddleware_Unauthorized(t *testing.T) {
+	h := newHandler(&mocks.MockCommandHandler{})
+
+	err := h.HandleCommand(context.Background(), mocks.Command{ID: 1})
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Error("there should be an unauthorized error:", err)
+	}
+}
+
+func TestMiddleware_Forbidden(t *testing.T) {
+	inner := &mocks.MockCommandHandler{BuFn: func(command.Command, command.Entity) error {
+		t.Error("the handler should not be called")
+		return nil
+	}}
+	h := newHandler(inner)
+	ctx := WithPrincipal(context.Background(), Principal{ID: "bob", Roles: []string{"user"}})
+
+	err := h.HandleCommand(ctx, mocks.Command{ID: 1})
+	var authzErr *Error
+	if !errors.Is(err, ErrForbidden) || !errors.As(err, &authzErr) {
+		t.Fatal("there should be a forbidden error:", err)
+	}
+	if authzErr.PrincipalID != "bob" || authzErr.CommandType != mocks.CommandType {
+		t.Error("the error should describe the principal and command:", authzErr)
+	}
+
+	other := command.Type("other")
+	err = NewRulePolicy().Authorize(ctx, &ownedCommand{cmdType: other}, nil)
+	if !errors.Is(err, ErrForbidden) {
+		t.Error("commands without rules should be forbidden:", err)
+	}
+}
+
+func TestMiddleware_Allowed(t *testing.T) {
+	h := newHandler(&mocks.MockCommandHandler{})
+
+	ctx := WithPrincipal(context.Background(), Principal{ID: "alice", Roles: []string{"admin"}})
+	if err := h.HandleCommand(ctx, mocks.Command{ID: 1}); err != nil {
+		t.Error("there should be no error:", err)
+	}
+
+	policy := NewRulePolicy().Allow(mocks.CommandType, RequireOwner(func(e command.Entity) string {
+		return e.(*mocks.SimpleModel).Content
+	}))
+	ctx = WithPrincipal(context.Background(), Principal{ID: "carol"})
+	cmd := &ownedCommand{cmdType: mocks.CommandType, entity: &mocks.SimpleModel{ID: 1, Content: "carol"}}
+	if err := policy.Authorize(ctx, cmd, cmd.Entity()); err != nil {
+		t.Error("the owner should be allowed:", err)
+	}
+}
+
+const renameCommandType = command.Type("authz.rename")
+
+type renameCommand struct {
+	ID int
+}
+
+func (c *renameCommand) CommandType() command.Type { return renameCommandType }
+func (c *renameCommand) Entity() command.Entity    { return &mocks.SimpleModel{ID: c.ID} }
+
+func TestMiddleware_LoadedEntity(t *testing.T) {
+	policy := NewRulePolicy().Allow(renameCommandType, RequireOwner(func(e command.Entity) string {
+		return e.(*mocks.SimpleModel).Content
+	}))
+	command.RegisterCommandHandler(renameCommandType,
+		command.UseHandlerMiddleware(&mocks.MockCommandHandler{}, NewMiddleware(policy)))
+	defer command.UnRegisterCommandHandler(renameCommandType)
+
+	r := memrepo.NewRepository()
+	if err := r.Save(&mocks.SimpleModel{ID: 1, Content: "alice"}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if err := r.Save(&mocks.SimpleModel{ID: 2}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(&mocks.MockCommandStore{}),
+		command.WithEventBus(&mocks.EventBus{}),
+	), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithPrincipal(context.Background(), Principal{ID: "alice"})
+	if err := ce.Execute(ctx, &renameCommand{ID: 1}); err != nil {
+		t.Error("the owner of the stored entity should be allowed:", err)
+	}
+
+	ctx = WithPrincipal(context.Background(), Principal{ID: "bob"})
+	if err := ce.Execute(ctx, &renameCommand{ID: 1}); !errors.Is(err, ErrForbidden) {
+		t.Error("there should be a forbidden error:", err)
+	}
+
+	// the stored entity has no owner
+	ctx = WithPrincipal(context.Background(), Principal{})
+	if err := ce.Execute(ctx, &renameCommand{ID: 2}); !errors.Is(err, ErrForbidden) {
+		t.Error("a principal without id should not own the entity:", err)
+	}
+}
+
+type ownedCommand struct {
+	cmdType command.Type
+	entity  command.Entity
+}
+
+func (c *ownedCommand) CommandType() command.Type { return c.cmdType }
+func (c *ownedCommand) Entity() command.Entity    { return c.entity }
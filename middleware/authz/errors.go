@@ -0,0 +1,39 @@
+package authz
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gapsquare/command"
+)
+
+// ErrUnauthorized error when there is no authenticated principal
+var ErrUnauthorized = errors.New("Unauthorized")
+
+// ErrForbidden error when the principal is not allowed to execute the command
+var ErrForbidden = errors.New("Forbidden")
+
+// Error is an authorization failure, it wraps ErrUnauthorized or ErrForbidden
+type Error struct {
+	Err         error
+	CommandType command.Type
+	PrincipalID string
+	Reason      string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%v: command %s", e.Err, e.CommandType)
+	if e.PrincipalID != "" {
+		msg += fmt.Sprintf(", principal %s", e.PrincipalID)
+	}
+	if e.Reason != "" {
+		msg += ", " + e.Reason
+	}
+	return msg
+}
+
+// Unwrap returns ErrUnauthorized or ErrForbidden
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,105 @@
+package authz
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gapsquare/command"
+)
+
+// Policy decides whether the caller may execute a command on an entity
+type Policy interface {
+	// Authorize returns nil if the command is allowed, an error wrapping ErrUnauthorized or ErrForbidden otherwise
+	Authorize(ctx context.Context, cmd command.Command, entity command.Entity) error
+}
+
+// PolicyFunc is a function implementing Policy
+type PolicyFunc func(ctx context.Context, cmd command.Command, entity command.Entity) error
+
+// Authorize implements the Policy interface
+func (f PolicyFunc) Authorize(ctx context.Context, cmd command.Command, entity command.Entity) error {
+	return f(ctx, cmd, entity)
+}
+
+// Rule returns true if the principal may execute the command on the entity
+type Rule func(ctx context.Context, p Principal, cmd command.Command, entity command.Entity) bool
+
+// Authenticated is a Rule allowing any authenticated principal
+func Authenticated() Rule {
+	return func(context.Context, Principal, command.Command, command.Entity) bool {
+		return true
+	}
+}
+
+// RequireRole is a Rule allowing principals having one of the roles
+func RequireRole(roles ...string) Rule {
+	return func(_ context.Context, p Principal, _ command.Command, _ command.Entity) bool {
+		return p.HasRole(roles...)
+	}
+}
+
+// RequireOwner is a Rule allowing the principal owning the entity, owner returns the owner id of an entity.
+// Principals without ID are never owners
+func RequireOwner(owner func(command.Entity) string) Rule {
+	return func(_ context.Context, p Principal, _ command.Command, entity command.Entity) bool {
+		return p.ID != "" && entity != nil && owner(entity) == p.ID
+	}
+}
+
+// AnyOf is a Rule allowing the principal if one of the rules allows it
+func AnyOf(rules ...Rule) Rule {
+	return func(ctx context.Context, p Principal, cmd command.Command, entity command.Entity) bool {
+		for _, rule := range rules {
+			if rule(ctx, p, cmd, entity) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RulePolicy is a Policy with rules per command type, all rules of a type must allow the principal.
+// Commands without rules are forbidden
+type RulePolicy struct {
+	mu    sync.RWMutex
+	rules map[command.Type][]Rule
+}
+
+var _ = Policy(&RulePolicy{})
+
+// NewRulePolicy creates a RulePolicy without rules
+func NewRulePolicy() *RulePolicy {
+	return &RulePolicy{rules: make(map[command.Type][]Rule)}
+}
+
+// Allow adds rules for a command type
+func (p *RulePolicy) Allow(cmdType command.Type, rules ...Rule) *RulePolicy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rules[cmdType] = append(p.rules[cmdType], rules...)
+	return p
+}
+
+// Authorize implements the Policy interface
+func (p *RulePolicy) Authorize(ctx context.Context, cmd command.Command, entity command.Entity) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return &Error{Err: ErrUnauthorized, CommandType: cmd.CommandType(), Reason: "no principal"}
+	}
+
+	p.mu.RLock()
+	rules := p.rules[cmd.CommandType()]
+	p.mu.RUnlock()
+
+	if len(rules) == 0 {
+		return &Error{Err: ErrForbidden, CommandType: cmd.CommandType(), PrincipalID: principal.ID, Reason: "no rule"}
+	}
+
+	for _, rule := range rules {
+		if !rule(ctx, principal, cmd, entity) {
+			return &Error{Err: ErrForbidden, CommandType: cmd.CommandType(), PrincipalID: principal.ID}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package authz
+
+import "context"
+
+// Principal is the authenticated caller executing a command
+type Principal struct {
+	ID    string
+	Roles []string
+	// Attributes are additional claims of the caller, e.g. its tenant or department
+	Attributes map[string]string
+}
+
+// HasRole returns true if the principal has one of the roles
+func (p Principal) HasRole(roles ...string) bool {
+	for _, have := range p.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type contextKey int
+
+const principalKey contextKey = iota
+
+// WithPrincipal returns a context carrying the principal
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// PrincipalFromContext returns the principal carried by the context
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey).(Principal)
+	return p, ok
+}
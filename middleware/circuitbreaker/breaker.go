@@ -0,0 +1,215 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gapsquare/command"
+)
+
+// State is the state of a circuit
+type State int
+
+// States of a circuit
+const (
+	// StateClosed lets the commands through and counts their failures
+	StateClosed State = iota
+	// StateOpen rejects the commands until the cool-down is over
+	StateOpen
+	// StateHalfOpen lets a few trial commands through, their success closes the circuit
+	StateHalfOpen
+)
+
+// String returns the name of the state
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Settings configures the circuit of a command type
+type Settings struct {
+	// FailureRatio opens the circuit when the ratio of failed commands reaches it, default 0.5
+	FailureRatio float64
+	// MinRequests is the number of commands required before the ratio is evaluated, default 5
+	MinRequests int
+	// Interval resets the counts of the closed state periodically, zero never resets them
+	Interval time.Duration
+	// CoolDown is the time the circuit stays open before it is half-open, default 30s
+	CoolDown time.Duration
+	// HalfOpenRequests is the number of successful trial commands closing the circuit, default 1
+	HalfOpenRequests int
+}
+
+func (s Settings) withDefaults() Settings {
+	if s.FailureRatio <= 0 {
+		s.FailureRatio = 0.5
+	}
+	if s.MinRequests <= 0 {
+		s.MinRequests = 5
+	}
+	if s.CoolDown <= 0 {
+		s.CoolDown = 30 * time.Second
+	}
+	if s.HalfOpenRequests <= 0 {
+		s.HalfOpenRequests = 1
+	}
+	return s
+}
+
+// Classifier returns true if the error of a handler counts as a failure,
+// errors not counting as failures count as successes
+type Classifier func(error) bool
+
+// DefaultClassifier counts every error as a failure, except the cancellation of the context
+func DefaultClassifier(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+// StateChangeFunc is called when the circuit of a command type changes its state
+type StateChangeFunc func(cmdType command.Type, from, to State)
+
+type circuit struct {
+	settings Settings
+	state    State
+	// generation changes with the state, results of commands allowed in a previous state are ignored
+	generation uint64
+
+	requests, failures int
+	windowStart        time.Time
+	openedAt           time.Time
+
+	halfOpenInFlight, halfOpenSuccesses int
+}
+
+type transition struct {
+	cmdType  command.Type
+	from, to State
+}
+
+type breaker struct {
+	mu       sync.Mutex
+	circuits map[command.Type]*circuit
+
+	settings   map[command.Type]Settings
+	fallback   Settings
+	classifier Classifier
+	onChange   []StateChangeFunc
+	now        func() time.Time
+}
+
+func (b *breaker) circuit(cmdType command.Type) *circuit {
+	c, ok := b.circuits[cmdType]
+	if !ok {
+		settings, ok := b.settings[cmdType]
+		if !ok {
+			settings = b.fallback
+		}
+		c = &circuit{settings: settings.withDefaults(), windowStart: b.now()}
+		b.circuits[cmdType] = c
+	}
+	return c
+}
+
+// allow returns the generation of the circuit if the command is allowed
+func (b *breaker) allow(cmdType command.Type) (uint64, error) {
+	b.mu.Lock()
+	var changes []transition
+	defer func() {
+		b.mu.Unlock()
+		b.notify(changes)
+	}()
+
+	c := b.circuit(cmdType)
+	now := b.now()
+
+	switch c.state {
+	case StateClosed:
+		if c.settings.Interval > 0 && now.Sub(c.windowStart) >= c.settings.Interval {
+			c.requests, c.failures = 0, 0
+			c.windowStart = now
+		}
+	case StateOpen:
+		if wait := c.openedAt.Add(c.settings.CoolDown).Sub(now); wait > 0 {
+			return 0, &Error{CommandType: cmdType, State: StateOpen, RetryAfter: wait}
+		}
+		changes = append(changes, b.setState(cmdType, c, StateHalfOpen, now))
+		fallthrough
+	case StateHalfOpen:
+		if c.halfOpenInFlight+c.halfOpenSuccesses >= c.settings.HalfOpenRequests {
+			return 0, &Error{CommandType: cmdType, State: StateHalfOpen}
+		}
+		c.halfOpenInFlight++
+	}
+
+	return c.generation, nil
+}
+
+// record records the result of a command allowed in the generation
+func (b *breaker) record(cmdType command.Type, generation uint64, failed bool) {
+	b.mu.Lock()
+	var changes []transition
+	defer func() {
+		b.mu.Unlock()
+		b.notify(changes)
+	}()
+
+	c := b.circuit(cmdType)
+	if c.generation != generation {
+		return
+	}
+
+	now := b.now()
+
+	switch c.state {
+	case StateClosed:
+		c.requests++
+		if failed {
+			c.failures++
+		}
+		if c.requests >= c.settings.MinRequests &&
+			float64(c.failures)/float64(c.requests) >= c.settings.FailureRatio {
+			changes = append(changes, b.setState(cmdType, c, StateOpen, now))
+		}
+	case StateHalfOpen:
+		c.halfOpenInFlight--
+		if failed {
+			changes = append(changes, b.setState(cmdType, c, StateOpen, now))
+			return
+		}
+		if c.halfOpenSuccesses++; c.halfOpenSuccesses >= c.settings.HalfOpenRequests {
+			changes = append(changes, b.setState(cmdType, c, StateClosed, now))
+		}
+	}
+}
+
+func (b *breaker) setState(cmdType command.Type, c *circuit, state State, now time.Time) transition {
+	t := transition{cmdType: cmdType, from: c.state, to: state}
+
+	c.state = state
+	c.generation++
+	c.requests, c.failures = 0, 0
+	c.halfOpenInFlight, c.halfOpenSuccesses = 0, 0
+	c.windowStart = now
+	if state == StateOpen {
+		c.openedAt = now
+	}
+	return t
+}
+
+// notify calls the state change callbacks, outside of the lock
+func (b *breaker) notify(changes []transition) {
+	for _, t := range changes {
+		for _, fn := range b.onChange {
+			fn(t.cmdType, t.from, t.to)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package circuitbreaker
+
+import (
+	"context"
+	"time"
+
+	"github.com/gapsquare/command"
+)
+
+// Option configures the circuit breaker of the middleware
+type Option func(*breaker)
+
+// WithSettings sets the settings of the circuit of a command type
+func WithSettings(cmdType command.Type, settings Settings) Option {
+	return func(b *breaker) {
+		b.settings[cmdType] = settings
+	}
+}
+
+// WithDefault sets the settings of the command types without their own settings
+func WithDefault(settings Settings) Option {
+	return func(b *breaker) {
+		b.fallback = settings
+	}
+}
+
+// WithClassifier sets the Classifier deciding which handler errors count as failures, default DefaultClassifier
+func WithClassifier(classifier Classifier) Option {
+	return func(b *breaker) {
+		b.classifier = classifier
+	}
+}
+
+// WithOnStateChange adds callbacks called when a circuit changes its state, e.g. to log it
+func WithOnStateChange(fns ...StateChangeFunc) Option {
+	return func(b *breaker) {
+		b.onChange = append(b.onChange, fns...)
+	}
+}
+
+// WithClock sets the function returning the current time, useful in tests
+func WithClock(now func() time.Time) Option {
+	return func(b *breaker) {
+		b.now = now
+	}
+}
+
+// NewMiddleware returns a middleware with a circuit per command type, commands are rejected
+// with an *Error while the circuit of their type is open
+func NewMiddleware(options ...Option) command.HandlerMiddleware {
+	b := &breaker{
+		circuits:   make(map[command.Type]*circuit),
+		settings:   make(map[command.Type]Settings),
+		classifier: DefaultClassifier,
+		now:        time.Now,
+	}
+	for _, option := range options {
+		option(b)
+	}
+
+	return command.HandlerMiddleware(func(h command.Handler) command.Handler {
+		return command.HandlerFunc(func(ctx context.Context, cmd command.Command) error {
+			generation, err := b.allow(cmd.CommandType())
+			if err != nil {
+				return err
+			}
+
+			// a panic of the handler is recorded as a failure before it is propagated
+			failed := true
+			defer func() { b.record(cmd.CommandType(), generation, failed) }()
+
+			// Immediate command execution.
+			err = h.HandleCommand(ctx, cmd)
+			failed = b.classifier(err)
+			return err
+		})
+	})
+}
@@ -0,0 +1,176 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gapsquare/command"
+
+	"github.com/gapsquare/command/mocks"
+)
+
+var errDownstream = errors.New("downstream unavailable")
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func TestMiddleware_OpenHalfOpenClose(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	inner := &mocks.MockCommandHandler{Err: errDownstream}
+	var changes []State
+	h := command.UseHandlerMiddleware(inner, NewMiddleware(
+		WithSettings(mocks.CommandType, Settings{FailureRatio: 0.5, MinRequests: 4, CoolDown: time.Second}),
+		WithClock(c.Now),
+		WithOnStateChange(func(cmdType command.Type, from, to State) {
+			if cmdType != mocks.CommandType {
+				t.Error("the state change should be of the command type:", cmdType)
+			}
+			changes = append(changes, to)
+		}),
+	))
+
+	for i := 0; i < 4; i++ {
+		if err := h.HandleCommand(context.Background(), mocks.Command{}); err != errDownstream {
+			t.Fatal("the handler error should be returned:", err)
+		}
+	}
+
+	err := h.HandleCommand(context.Background(), mocks.Command{})
+	var openErr *Error
+	if !errors.Is(err, ErrOpen) || !errors.As(err, &openErr) {
+		t.Fatal("the circuit should be open:", err)
+	}
+	if openErr.State != StateOpen || openErr.RetryAfter != time.Second {
+		t.Error("the error should have the remaining cool-down:", openErr)
+	}
+
+	// a failed trial opens the circuit again
+	c.now = c.now.Add(time.Second)
+	if err := h.HandleCommand(context.Background(), mocks.Command{}); err != errDownstream {
+		t.Error("a trial command should be let through:", err)
+	}
+	if err := h.HandleCommand(context.Background(), mocks.Command{}); !errors.Is(err, ErrOpen) {
+		t.Error("the circuit should be open again:", err)
+	}
+
+	// a successful trial closes the circuit
+	c.now = c.now.Add(time.Second)
+	inner.Err = nil
+	for i := 0; i < 2; i++ {
+		if err := h.HandleCommand(context.Background(), mocks.Command{}); err != nil {
+			t.Error("there should be no error:", err)
+		}
+	}
+
+	expected := []State{StateOpen, StateHalfOpen, StateOpen, StateHalfOpen, StateClosed}
+	if len(changes) != len(expected) {
+		t.Fatal("unexpected state changes:", changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Error("unexpected state changes:", changes)
+		}
+	}
+}
+
+func TestMiddleware_Classifier(t *testing.T) {
+	errInvalid := errors.New("invalid")
+	inner := &mocks.MockCommandHandler{Err: errInvalid}
+	h := command.UseHandlerMiddleware(inner, NewMiddleware(
+		WithDefault(Settings{MinRequests: 1, FailureRatio: 0.25}),
+		WithClassifier(func(err error) bool {
+			return DefaultClassifier(err) && !errors.Is(err, errInvalid)
+		}),
+	))
+
+	for i := 0; i < 3; i++ {
+		if err := h.HandleCommand(context.Background(), mocks.Command{}); err != errInvalid {
+			t.Error("errors not counting as failures should not open the circuit:", err)
+		}
+	}
+
+	inner.Err = errDownstream
+	h.HandleCommand(context.Background(), mocks.Command{})
+	if err := h.HandleCommand(context.Background(), mocks.Command{}); !errors.Is(err, ErrOpen) {
+		t.Error("the circuit should be open:", err)
+	}
+}
+
+func TestMiddleware_HalfOpenLimitsTrials(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fail := true
+	inner := &mocks.MockCommandHandler{BuFn: func(command.Command, command.Entity) error {
+		if fail {
+			return errDownstream
+		}
+		started <- struct{}{}
+		<-release
+		return nil
+	}}
+	h := command.UseHandlerMiddleware(inner, NewMiddleware(
+		WithDefault(Settings{MinRequests: 1, CoolDown: time.Second}),
+		WithClock(c.Now),
+	))
+
+	h.HandleCommand(context.Background(), mocks.Command{})
+	c.now = c.now.Add(time.Second)
+	fail = false
+
+	done := make(chan error, 1)
+	go func() { done <- h.HandleCommand(context.Background(), mocks.Command{}) }()
+	<-started
+
+	var openErr *Error
+	if err := h.HandleCommand(context.Background(), mocks.Command{}); !errors.As(err, &openErr) || openErr.State != StateHalfOpen {
+		t.Error("only one trial command should be let through:", err)
+	}
+
+	release <- struct{}{}
+	if err := <-done; err != nil {
+		t.Error("there should be no error:", err)
+	}
+}
+
+func TestMiddleware_PanicIsFailure(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	panics := true
+	inner := &mocks.MockCommandHandler{BuFn: func(command.Command, command.Entity) error {
+		if panics {
+			panic("downstream")
+		}
+		return nil
+	}}
+	h := command.UseHandlerMiddleware(inner, NewMiddleware(
+		WithDefault(Settings{MinRequests: 1, CoolDown: time.Second}),
+		WithClock(c.Now),
+	))
+	handle := func() (err error) {
+		defer func() {
+			if r := recover(); r == nil && panics {
+				t.Error("the panic should be propagated")
+			}
+		}()
+		return h.HandleCommand(context.Background(), mocks.Command{})
+	}
+
+	handle()
+	if err := h.HandleCommand(context.Background(), mocks.Command{}); !errors.Is(err, ErrOpen) {
+		t.Error("the panic should open the circuit:", err)
+	}
+
+	// the panicking trial frees its half-open slot
+	c.now = c.now.Add(time.Second)
+	handle()
+	c.now = c.now.Add(time.Second)
+	panics = false
+	if err := handle(); err != nil {
+		t.Error("the trial after the cool-down should be let through:", err)
+	}
+}
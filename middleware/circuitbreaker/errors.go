@@ -0,0 +1,34 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gapsquare/command"
+)
+
+// ErrOpen error when a command is rejected because the circuit of its type is open
+var ErrOpen = errors.New("Circuit open")
+
+// Error is a rejection of the circuit breaker, it wraps ErrOpen
+type Error struct {
+	CommandType command.Type
+	State       State
+	// RetryAfter is the remaining cool-down, zero in the half-open state
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%v: command %s, state %s", ErrOpen, e.CommandType, e.State)
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(", retry after %v", e.RetryAfter)
+	}
+	return msg
+}
+
+// Unwrap returns ErrOpen
+func (e *Error) Unwrap() error {
+	return ErrOpen
+}
@@ -0,0 +1,44 @@
+package interceptors
+
+import (
+	"context"
+
+	"github.com/gapsquare/command"
+)
+
+// AfterCommand is a command with an interceptor called after it is handled
+type AfterCommand interface {
+	command.Command
+	// AfterExecute is called with the error returned by the handler, nil on success
+	AfterExecute(ctx context.Context, err error)
+}
+
+// NewAfterMiddleware returns a middleware calling AfterExecute of commands after they are handled
+func NewAfterMiddleware() command.HandlerMiddleware {
+	return command.HandlerMiddleware(func(h command.Handler) command.Handler {
+		return command.HandlerFunc(func(ctx context.Context, cmd command.Command) error {
+			err := h.HandleCommand(ctx, cmd)
+
+			// Call the after interceptor if it exists
+			if c, ok := cmd.(AfterCommand); ok {
+				c.AfterExecute(ctx, err)
+			}
+
+			return err
+		})
+	})
+}
+
+// CommandInterceptAfter returns a wrapped command with after interceptor method
+func CommandInterceptAfter(cmd command.Command, a func(context.Context, error)) AfterCommand {
+	c := intercept(cmd)
+	c.after = a
+	return c
+}
+
+//AfterExecute implements the AfterCommand interface
+func (c *commandImp) AfterExecute(ctx context.Context, err error) {
+	if c.after != nil {
+		c.after(ctx, err)
+	}
+}
@@ -11,11 +11,22 @@ type Command interface {
 	BeforeExecute() error
 }
 
+// ContextCommand is a command with a context aware before interceptor,
+// it is preferred over BeforeExecute when a command implements both
+type ContextCommand interface {
+	command.Command
+	BeforeExecuteContext(context.Context) error
+}
+
 func NewBeforeMiddleware() command.HandlerMiddleware {
 	return command.HandlerMiddleware(func(h command.Handler) command.Handler {
 		return command.HandlerFunc(func(ctx context.Context, cmd command.Command) error {
-			// Call the validation method if it exists
-			if c, ok := cmd.(Command); ok {
+			// Call the before interceptor if it exists
+			if c, ok := cmd.(ContextCommand); ok {
+				if err := c.BeforeExecuteContext(ctx); err != nil {
+					return err
+				}
+			} else if c, ok := cmd.(Command); ok {
 				err := c.BeforeExecute()
 				if err != nil {
 					return err
@@ -30,15 +41,56 @@ func NewBeforeMiddleware() command.HandlerMiddleware {
 
 // CommandInterceptBefore returns a wrapped command with before interceptor method
 func CommandInterceptBefore(cmd command.Command, b func() error) Command {
-	return &commandImp{Command: cmd, before: b}
+	c := intercept(cmd)
+	c.before = b
+	return c
+}
+
+// CommandInterceptBeforeContext returns a wrapped command with context aware before interceptor method
+func CommandInterceptBeforeContext(cmd command.Command, b func(context.Context) error) ContextCommand {
+	c := intercept(cmd)
+	c.beforeContext = b
+	return c
 }
 
+// commandImp wraps a command with interceptor methods, wrapping it again keeps the interceptors already set
 type commandImp struct {
 	command.Command
-	before func() error
+	before        func() error
+	beforeContext func(context.Context) error
+	after         func(context.Context, error)
+	onError       func(context.Context, error) error
+}
+
+// Unwrap implements the command.Wrapper interface
+func (c *commandImp) Unwrap() command.Command {
+	return c.Command
+}
+
+// intercept returns a copy of cmd if it is already wrapped, otherwise a new wrapper
+func intercept(cmd command.Command) *commandImp {
+	if c, ok := cmd.(*commandImp); ok {
+		wrapped := *c
+		return &wrapped
+	}
+	return &commandImp{Command: cmd}
 }
 
 //BeforeExecute implements the Command interface
 func (c *commandImp) BeforeExecute() error {
+	if c.before == nil {
+		if c.beforeContext != nil {
+			return c.beforeContext(context.Background())
+		}
+		return nil
+	}
 	return c.before()
 }
+
+//BeforeExecuteContext implements the ContextCommand interface
+func (c *commandImp) BeforeExecuteContext(ctx context.Context) error {
+	if c.beforeContext == nil {
+		return c.BeforeExecute()
+	}
+	return c.beforeContext(ctx)
+}
@@ -0,0 +1,102 @@
+package interceptors
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gapsquare/command"
+
+	"github.com/gapsquare/command/mocks"
+)
+
+func TestInterceptorsMiddleware(t *testing.T) {
+	handlerErr := errors.New("handler error")
+	inner := &mocks.MockCommandHandler{Err: handlerErr}
+	h := command.UseHandlerMiddleware(inner, NewBeforeMiddleware(), NewAfterMiddleware(), NewOnErrorMiddleware())
+
+	var calls []string
+	var afterErr error
+	cmd := CommandInterceptBefore(&mocks.Command{ID: 1}, func() error {
+		calls = append(calls, "before")
+		return nil
+	})
+	c := CommandInterceptOnError(CommandInterceptAfter(cmd, func(ctx context.Context, err error) {
+		calls = append(calls, "after")
+		afterErr = err
+	}), func(ctx context.Context, err error) error {
+		calls = append(calls, "error")
+		return nil
+	})
+
+	if err := h.HandleCommand(context.Background(), c); err != nil {
+		t.Error("the error should be suppressed by OnError:", err)
+	}
+	if len(calls) != 3 || calls[0] != "before" || calls[1] != "error" || calls[2] != "after" {
+		t.Error("all interceptors should be called in order:", calls)
+	}
+	if afterErr != nil {
+		t.Error("AfterExecute should see the error returned by OnError:", afterErr)
+	}
+}
+
+func TestOnErrorMiddlewareReplacesError(t *testing.T) {
+	inner := &mocks.MockCommandHandler{Err: errors.New("handler error")}
+	h := command.UseHandlerMiddleware(inner, NewOnErrorMiddleware())
+
+	replaced := errors.New("replaced")
+	c := CommandInterceptOnError(&mocks.Command{ID: 1}, func(ctx context.Context, err error) error {
+		return replaced
+	})
+	if err := h.HandleCommand(context.Background(), c); err != replaced {
+		t.Error("the error should be replaced:", err)
+	}
+
+	inner.Err = nil
+	c = CommandInterceptOnError(&mocks.Command{ID: 1}, func(ctx context.Context, err error) error {
+		t.Error("OnError should not be called on success")
+		return err
+	})
+	if err := h.HandleCommand(context.Background(), c); err != nil {
+		t.Error("there should be no error:", err)
+	}
+}
+
+func TestAfterMiddlewareReceivesHandlerError(t *testing.T) {
+	handlerErr := errors.New("handler error")
+	inner := &mocks.MockCommandHandler{Err: handlerErr}
+	h := command.UseHandlerMiddleware(inner, NewAfterMiddleware())
+
+	var afterErr error
+	c := CommandInterceptAfter(&mocks.Command{ID: 1}, func(ctx context.Context, err error) {
+		afterErr = err
+	})
+	if err := h.HandleCommand(context.Background(), c); err != handlerErr {
+		t.Error("the handler error should be returned:", err)
+	}
+	if afterErr != handlerErr {
+		t.Error("AfterExecute should receive the handler error:", afterErr)
+	}
+}
+
+func TestBeforeMiddlewarePrefersContext(t *testing.T) {
+	inner := &mocks.MockCommandHandler{}
+	h := command.UseHandlerMiddleware(inner, NewBeforeMiddleware())
+
+	c := CommandInterceptBeforeContext(CommandInterceptBefore(&mocks.Command{ID: 1}, func() error {
+		t.Error("BeforeExecute should not be called")
+		return nil
+	}), func(ctx context.Context) error {
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := h.HandleCommand(ctx, c); err != nil {
+		t.Error("there should be no error:", err)
+	}
+
+	cancel()
+	if err := h.HandleCommand(ctx, c); err != context.Canceled {
+		t.Error("the context error should be returned:", err)
+	}
+}
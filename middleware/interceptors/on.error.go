@@ -0,0 +1,49 @@
+package interceptors
+
+import (
+	"context"
+
+	"github.com/gapsquare/command"
+)
+
+// ErrorCommand is a command with an interceptor called when handling it fails
+type ErrorCommand interface {
+	command.Command
+	// OnError is called with the error returned by the handler, the returned error replaces it,
+	// returning nil suppresses it
+	OnError(ctx context.Context, err error) error
+}
+
+// NewOnErrorMiddleware returns a middleware calling OnError of commands when their handler fails
+func NewOnErrorMiddleware() command.HandlerMiddleware {
+	return command.HandlerMiddleware(func(h command.Handler) command.Handler {
+		return command.HandlerFunc(func(ctx context.Context, cmd command.Command) error {
+			err := h.HandleCommand(ctx, cmd)
+			if err == nil {
+				return nil
+			}
+
+			// Call the error interceptor if it exists
+			if c, ok := cmd.(ErrorCommand); ok {
+				return c.OnError(ctx, err)
+			}
+
+			return err
+		})
+	})
+}
+
+// CommandInterceptOnError returns a wrapped command with error interceptor method
+func CommandInterceptOnError(cmd command.Command, e func(context.Context, error) error) ErrorCommand {
+	c := intercept(cmd)
+	c.onError = e
+	return c
+}
+
+//OnError implements the ErrorCommand interface
+func (c *commandImp) OnError(ctx context.Context, err error) error {
+	if c.onError == nil {
+		return err
+	}
+	return c.onError(ctx, err)
+}
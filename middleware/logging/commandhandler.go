@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/gapsquare/command"
+)
+
+// NewMiddleware returns a middleware logging every handled command with its type, entity ID, duration,
+// outcome and error class, and the metadata and tenant of the context. A nil logger uses slog.Default
+func NewMiddleware(l *slog.Logger, options ...Option) command.HandlerMiddleware {
+	lg := newLogger(l, options...)
+
+	return command.HandlerMiddleware(func(h command.Handler) command.Handler {
+		return command.HandlerFunc(func(ctx context.Context, cmd command.Command) error {
+			start := time.Now()
+
+			// Immediate command execution.
+			err := h.HandleCommand(ctx, cmd)
+
+			lg.log(ctx, "command handled", cmd, time.Since(start), err)
+			return err
+		})
+	})
+}
+
+type executer struct {
+	next   command.Executer
+	logger *logger
+}
+
+// NewExecuter returns an Executer logging every execution of next, see NewMiddleware
+func NewExecuter(next command.Executer, l *slog.Logger, options ...Option) command.Executer {
+	return &executer{next: next, logger: newLogger(l, options...)}
+}
+
+// Execute implements the command.Executer interface
+func (e *executer) Execute(ctx context.Context, cmd command.Command) error {
+	start := time.Now()
+	err := e.next.Execute(ctx, cmd)
+	e.logger.log(ctx, "command executed", cmd, time.Since(start), err)
+	return err
+}
+
+func (l *logger) log(ctx context.Context, msg string, cmd command.Command, duration time.Duration, err error) {
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = l.classify(err)
+	}
+
+	level := l.level(outcome)
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("command_type", string(cmd.CommandType())),
+	}
+	if entity := cmd.Entity(); entity != nil {
+		attrs = append(attrs, slog.String("entity_id", entity.EntityID().String()))
+	}
+	attrs = append(attrs, slog.Duration("duration", duration))
+
+	if err == nil {
+		attrs = append(attrs, slog.String("outcome", OutcomeSuccess))
+	} else {
+		attrs = append(attrs,
+			slog.String("outcome", "failure"),
+			slog.String("error_class", outcome),
+			slog.String("error", err.Error()))
+	}
+
+	if tenant, ok := command.TenantFromContext(ctx); ok {
+		attrs = append(attrs, slog.String(command.MetadataTenantID, string(tenant)))
+	}
+	if metadata := command.MetadataFromContext(ctx); len(metadata) > 0 {
+		keys := make([]string, 0, len(metadata))
+		for k := range metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		group := make([]interface{}, len(keys))
+		for i, k := range keys {
+			group[i] = slog.String(k, metadata[k])
+		}
+		attrs = append(attrs, slog.Group("metadata", group...))
+	}
+
+	if l.fields {
+		if fields := l.commandFields(cmd); len(fields) > 0 {
+			group := make([]interface{}, len(fields))
+			for i, f := range fields {
+				group[i] = f
+			}
+			attrs = append(attrs, slog.Group("command", group...))
+		}
+	}
+
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
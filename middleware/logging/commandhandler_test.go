@@ -0,0 +1,189 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gapsquare/command"
+
+	"github.com/gapsquare/command/mocks"
+)
+
+type loginCommand struct {
+	User     string
+	Password string `log:"redact"`
+	Token    string `log:"-"`
+	Email    string
+}
+
+func (c *loginCommand) CommandType() command.Type { return mocks.CommandType }
+func (c *loginCommand) Entity() command.Entity    { return &mocks.SimpleModel{ID: 7} }
+
+func newBufferLogger() (*slog.Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})), buf
+}
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	record := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal("the record should be json:", err, buf.String())
+	}
+	buf.Reset()
+	return record
+}
+
+func TestMiddleware_LogsSuccess(t *testing.T) {
+	l, buf := newBufferLogger()
+	h := command.UseHandlerMiddleware(&mocks.MockCommandHandler{}, NewMiddleware(l,
+		WithCommandFields(RedactFields("email"))))
+
+	ctx := command.WithCorrelationID(context.Background(), "corr-1")
+	ctx = command.WithTenant(ctx, "acme")
+	cmd := &loginCommand{User: "alice", Password: "secret", Token: "token", Email: "alice@example.com"}
+	if err := h.HandleCommand(ctx, cmd); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	record := decode(t, buf)
+	if record["level"] != "INFO" || record["msg"] != "command handled" || record["outcome"] != OutcomeSuccess {
+		t.Error("the success should be logged at info level:", record)
+	}
+	if record["command_type"] != string(mocks.CommandType) || record["entity_id"] != "7" || record["tenant_id"] != "acme" {
+		t.Error("the command should be described:", record)
+	}
+	if _, ok := record["duration"]; !ok {
+		t.Error("the duration should be logged:", record)
+	}
+	if metadata, _ := record["metadata"].(map[string]interface{}); metadata[command.MetadataCorrelationID] != "corr-1" {
+		t.Error("the correlation ID should be logged:", record)
+	}
+
+	fields, _ := record["command"].(map[string]interface{})
+	if fields["User"] != "alice" || fields["Password"] != Redacted || fields["Email"] != Redacted {
+		t.Error("the sensitive fields should be redacted:", fields)
+	}
+	if _, ok := fields["Token"]; ok {
+		t.Error("the omitted fields should not be logged:", fields)
+	}
+}
+
+type card struct {
+	Number string `log:"redact"`
+	CVC    string
+	Holder string
+}
+
+type payCommand struct {
+	Card    *card
+	Cards   []card
+	Byname  map[string]card
+	Headers map[string]string
+	Expires time.Time
+}
+
+func (c *payCommand) CommandType() command.Type { return mocks.CommandType }
+func (c *payCommand) Entity() command.Entity    { return &mocks.SimpleModel{ID: 7} }
+
+func TestMiddleware_RedactsNestedFields(t *testing.T) {
+	l, buf := newBufferLogger()
+	h := command.UseHandlerMiddleware(&mocks.MockCommandHandler{}, NewMiddleware(l,
+		WithCommandFields(RedactFields("cvc"))))
+
+	c := card{Number: "4111-1111", CVC: "cvc-123", Holder: "alice"}
+	cmd := &payCommand{Card: &c, Cards: []card{c}, Byname: map[string]card{"main": c}, Expires: time.Now()}
+	if err := h.HandleCommand(context.Background(), cmd); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	if out := buf.String(); strings.Contains(out, "4111-1111") || strings.Contains(out, "cvc-123") {
+		t.Error("the nested sensitive fields should be redacted:", out)
+	}
+
+	fields, _ := decode(t, buf)["command"].(map[string]interface{})
+	nested, _ := fields["Card"].(map[string]interface{})
+	if nested["Number"] != Redacted || nested["CVC"] != Redacted || nested["Holder"] != "alice" {
+		t.Error("the nested fields should be logged with their redaction:", fields)
+	}
+	if cards, _ := fields["Cards"].(map[string]interface{}); cards["0"].(map[string]interface{})["Holder"] != "alice" {
+		t.Error("the slices of structs should be logged as groups:", fields)
+	}
+	if _, ok := fields["Expires"].(string); !ok {
+		t.Error("the structs without exported fields should be logged as values:", fields)
+	}
+}
+
+func TestMiddleware_RedactsMapEntries(t *testing.T) {
+	l, buf := newBufferLogger()
+	h := command.UseHandlerMiddleware(&mocks.MockCommandHandler{}, NewMiddleware(l,
+		WithCommandFields(RedactFields("authorization"))))
+
+	cmd := &payCommand{Headers: map[string]string{"Authorization": "bearer-s3cr3t", "Accept": "json"}}
+	if err := h.HandleCommand(context.Background(), cmd); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	if out := buf.String(); strings.Contains(out, "bearer-s3cr3t") {
+		t.Error("the sensitive map entries should be redacted:", out)
+	}
+
+	fields, _ := decode(t, buf)["command"].(map[string]interface{})
+	headers, _ := fields["Headers"].(map[string]interface{})
+	if headers["Authorization"] != Redacted || headers["Accept"] != "json" {
+		t.Error("the map entries should be logged with their redaction:", fields)
+	}
+}
+
+func TestMiddleware_LogsFailure(t *testing.T) {
+	l, buf := newBufferLogger()
+	inner := &mocks.MockCommandHandler{Err: command.ErrEntityNotFound}
+	h := command.UseHandlerMiddleware(inner, NewMiddleware(l, WithLevel(ClassNotFound, slog.LevelWarn)))
+
+	if err := h.HandleCommand(context.Background(), mocks.Command{}); err != command.ErrEntityNotFound {
+		t.Fatal("the handler error should be returned:", err)
+	}
+	record := decode(t, buf)
+	if record["level"] != "WARN" || record["outcome"] != "failure" || record["error_class"] != ClassNotFound {
+		t.Error("the failure should be logged with its level and class:", record)
+	}
+	if _, ok := record["command"]; ok {
+		t.Error("the command fields should not be logged by default:", record)
+	}
+
+	errPayment := errors.New("payment declined")
+	inner.Err = errPayment
+	h = command.UseHandlerMiddleware(inner, NewMiddleware(l, WithClassifier(func(err error) string {
+		if errors.Is(err, errPayment) {
+			return "payment"
+		}
+		return ""
+	})))
+	h.HandleCommand(context.Background(), mocks.Command{})
+	if record := decode(t, buf); record["level"] != "ERROR" || record["error_class"] != "payment" {
+		t.Error("the classifier should set the error class:", record)
+	}
+}
+
+type executerFunc func(context.Context, command.Command) error
+
+func (f executerFunc) Execute(ctx context.Context, cmd command.Command) error { return f(ctx, cmd) }
+
+func TestExecuter_Logs(t *testing.T) {
+	l, buf := newBufferLogger()
+	ce := NewExecuter(executerFunc(func(context.Context, command.Command) error {
+		return &command.TimeoutError{CommandType: mocks.CommandType, Stage: command.StageHandle, Err: context.DeadlineExceeded}
+	}), l)
+
+	if err := ce.Execute(context.Background(), mocks.Command{}); err == nil {
+		t.Fatal("the execution error should be returned")
+	}
+	if record := decode(t, buf); record["msg"] != "command executed" || record["error_class"] != ClassTimeout {
+		t.Error("the execution should be logged:", record)
+	}
+}
@@ -0,0 +1,155 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gapsquare/command"
+)
+
+// tagName is the struct tag of the command fields redaction
+const tagName = "log"
+
+// Redacted is the value logged for redacted fields
+const Redacted = "[REDACTED]"
+
+// maxDepth is the depth of the nested values walked, deeper values are redacted
+const maxDepth = 16
+
+// Redactor returns the logged value of a command field, false omits the field.
+// The field of a nested struct, slice or map value is its dotted path, e.g. Card.Number or Items.0.Price
+type Redactor func(cmd command.Command, field string, value interface{}) (interface{}, bool)
+
+// RedactFields is a Redactor redacting the fields with the given names, e.g. password.
+// A name matches the field name at any depth, or its full dotted path
+func RedactFields(names ...string) Redactor {
+	return func(_ command.Command, field string, value interface{}) (interface{}, bool) {
+		short := field[strings.LastIndex(field, ".")+1:]
+		for _, name := range names {
+			if strings.EqualFold(field, name) || strings.EqualFold(short, name) {
+				return Redacted, true
+			}
+		}
+		return value, true
+	}
+}
+
+// commandFields returns the exported fields of the command, nil if it is not a struct
+func (l *logger) commandFields(cmd command.Command) []slog.Attr {
+	v := indirect(reflect.ValueOf(cmd))
+	if !isStruct(v) {
+		return nil
+	}
+	return l.structFields(cmd, "", v)
+}
+
+// structFields returns the exported fields of a struct, nested values are walked so their fields are redacted too
+func (l *logger) structFields(cmd command.Command, path string, v reflect.Value) []slog.Attr {
+	var attrs []slog.Attr
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		var value interface{} = v.Field(i).Interface()
+		switch f.Tag.Get(tagName) {
+		case "-":
+			continue
+		case "redact":
+			value = Redacted
+		}
+
+		if attr, ok := l.field(cmd, join(path, f.Name), f.Name, value); ok {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
+
+// field applies the redactors to a value and walks it if it is a struct, a slice of structs or a map,
+// the entries of maps are always walked as their keys may name sensitive values, e.g. headers
+func (l *logger) field(cmd command.Command, path, name string, value interface{}) (slog.Attr, bool) {
+	ok := true
+	for _, redact := range l.redactors {
+		if value, ok = redact(cmd, path, value); !ok {
+			return slog.Attr{}, false
+		}
+	}
+
+	v := indirect(reflect.ValueOf(value))
+	if strings.Count(path, ".") >= maxDepth {
+		switch v.Kind() {
+		case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+			return slog.String(name, Redacted), true
+		}
+	}
+
+	switch {
+	case isStruct(v):
+		return slog.Attr{Key: name, Value: slog.GroupValue(l.structFields(cmd, path, v)...)}, true
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && nested(v.Type().Elem()):
+		var attrs []slog.Attr
+		for i := 0; i < v.Len(); i++ {
+			key := strconv.Itoa(i)
+			if attr, ok := l.field(cmd, join(path, key), key, v.Index(i).Interface()); ok {
+				attrs = append(attrs, attr)
+			}
+		}
+		return slog.Attr{Key: name, Value: slog.GroupValue(attrs...)}, true
+	case v.Kind() == reflect.Map:
+		var attrs []slog.Attr
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			if attr, ok := l.field(cmd, join(path, key), key, iter.Value().Interface()); ok {
+				attrs = append(attrs, attr)
+			}
+		}
+		sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+		return slog.Attr{Key: name, Value: slog.GroupValue(attrs...)}, true
+	}
+	return slog.Any(name, value), true
+}
+
+// indirect dereferences pointers and interfaces, a nil one is returned as is
+func indirect(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+// isStruct returns true for structs with exported fields, others like time.Time are logged as values
+func isStruct(v reflect.Value) bool {
+	return v.Kind() == reflect.Struct && hasExportedFields(v.Type())
+}
+
+// nested returns true if the values of a type may hold struct fields
+func nested(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Interface || t.Kind() == reflect.Struct && hasExportedFields(t)
+}
+
+func hasExportedFields(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
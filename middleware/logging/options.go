@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/gapsquare/command"
+)
+
+// Outcomes and error classes logged with the commands
+const (
+	OutcomeSuccess = "success"
+
+	ClassNotFound        = "not_found"
+	ClassVersionMismatch = "version_mismatch"
+	ClassConflict        = "conflict"
+	ClassPrecondition    = "precondition"
+	ClassTenant          = "tenant"
+	ClassTimeout         = "timeout"
+	ClassCanceled        = "canceled"
+	ClassError           = "error"
+)
+
+// Classifier returns the class of an error, an empty class falls back to DefaultClassifier
+type Classifier func(error) string
+
+// DefaultClassifier classifies the errors of the command package
+func DefaultClassifier(err error) string {
+	var timeoutErr *command.TimeoutError
+	var preconditionErr *command.PreconditionError
+
+	switch {
+	case errors.As(err, &timeoutErr):
+		return ClassTimeout
+	case errors.As(err, &preconditionErr):
+		return ClassPrecondition
+	case errors.Is(err, command.ErrEntityNotFound):
+		return ClassNotFound
+	case errors.Is(err, command.ErrVersionMismatched):
+		return ClassVersionMismatch
+	case errors.Is(err, command.ErrConcurrencyConflict):
+		return ClassConflict
+	case errors.Is(err, command.ErrTenantMissing), errors.Is(err, command.ErrTenantMismatch):
+		return ClassTenant
+	case errors.Is(err, context.DeadlineExceeded):
+		return ClassTimeout
+	case errors.Is(err, context.Canceled):
+		return ClassCanceled
+	}
+	return ClassError
+}
+
+// Option configures the logging
+type Option func(*logger)
+
+// WithLevel sets the level of an outcome, OutcomeSuccess or an error class.
+// Default slog.LevelInfo for OutcomeSuccess and slog.LevelError for the error classes
+func WithLevel(outcome string, level slog.Level) Option {
+	return func(l *logger) {
+		l.levels[outcome] = level
+	}
+}
+
+// WithClassifier sets the Classifier of the errors
+func WithClassifier(classifier Classifier) Option {
+	return func(l *logger) {
+		l.classifier = classifier
+	}
+}
+
+// WithCommandFields logs the exported fields of the commands. Fields tagged `log:"redact"` are logged
+// as "[REDACTED]" and fields tagged `log:"-"` are omitted, the redactors are then applied in order.
+// Nested structs, and slices and maps of structs, are logged as groups with their fields redacted the same way
+func WithCommandFields(redactors ...Redactor) Option {
+	return func(l *logger) {
+		l.fields = true
+		l.redactors = append(l.redactors, redactors...)
+	}
+}
+
+type logger struct {
+	logger     *slog.Logger
+	levels     map[string]slog.Level
+	classifier Classifier
+	fields     bool
+	redactors  []Redactor
+}
+
+func newLogger(l *slog.Logger, options ...Option) *logger {
+	if l == nil {
+		l = slog.Default()
+	}
+
+	lg := &logger{
+		logger: l,
+		levels: map[string]slog.Level{OutcomeSuccess: slog.LevelInfo},
+	}
+	for _, option := range options {
+		option(lg)
+	}
+	return lg
+}
+
+func (l *logger) classify(err error) string {
+	if l.classifier != nil {
+		if class := l.classifier(err); class != "" {
+			return class
+		}
+	}
+	return DefaultClassifier(err)
+}
+
+func (l *logger) level(outcome string) slog.Level {
+	if level, ok := l.levels[outcome]; ok {
+		return level
+	}
+	return slog.LevelError
+}
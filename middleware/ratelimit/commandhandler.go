@@ -0,0 +1,35 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/gapsquare/command"
+)
+
+// NewMiddleware returns a middleware which rejects commands exceeding the limits of their type with an *Error
+func NewMiddleware(options ...Option) command.HandlerMiddleware {
+	l := &limiter{
+		limits:   make(map[command.Type]Limit),
+		key:      func(context.Context, command.Command) string { return "" },
+		now:      time.Now,
+		buckets:  make(map[limitKey]*bucket),
+		inFlight: make(map[limitKey]int),
+	}
+	for _, option := range options {
+		option(l)
+	}
+
+	return command.HandlerMiddleware(func(h command.Handler) command.Handler {
+		return command.HandlerFunc(func(ctx context.Context, cmd command.Command) error {
+			release, err := l.acquire(ctx, cmd)
+			if err != nil {
+				return err
+			}
+			defer release()
+
+			// Immediate command execution.
+			return h.HandleCommand(ctx, cmd)
+		})
+	})
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gapsquare/command"
+
+	"github.com/gapsquare/command/mocks"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func TestMiddleware_RateLimit(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	h := command.UseHandlerMiddleware(&mocks.MockCommandHandler{}, NewMiddleware(
+		WithLimit(mocks.CommandType, Limit{Rate: 2, Per: time.Second}),
+		WithClock(c.Now),
+	))
+
+	for i := 0; i < 2; i++ {
+		if err := h.HandleCommand(context.Background(), mocks.Command{ID: 1}); err != nil {
+			t.Fatal("there should be no error:", err)
+		}
+	}
+
+	err := h.HandleCommand(context.Background(), mocks.Command{ID: 1})
+	var rlErr *Error
+	if !errors.Is(err, ErrRateLimited) || !errors.As(err, &rlErr) {
+		t.Fatal("there should be a rate limited error:", err)
+	}
+	if rlErr.CommandType != mocks.CommandType || rlErr.RetryAfter != 500*time.Millisecond {
+		t.Error("the error should have a retry after hint of 500ms:", rlErr)
+	}
+
+	c.now = c.now.Add(rlErr.RetryAfter)
+	if err := h.HandleCommand(context.Background(), mocks.Command{ID: 1}); err != nil {
+		t.Error("there should be no error after the retry after hint:", err)
+	}
+
+	other := command.UseHandlerMiddleware(&mocks.MockCommandHandler{}, NewMiddleware(
+		WithLimit(command.Type("other"), Limit{Rate: 1, Per: time.Hour}),
+	))
+	for i := 0; i < 3; i++ {
+		if err := other.HandleCommand(context.Background(), mocks.Command{ID: 1}); err != nil {
+			t.Error("command types without limit should not be limited:", err)
+		}
+	}
+}
+
+func TestMiddleware_RateLimitByKey(t *testing.T) {
+	c := &clock{now: time.Unix(0, 0)}
+	h := command.UseHandlerMiddleware(&mocks.MockCommandHandler{}, NewMiddleware(
+		WithLimit(mocks.CommandType, Limit{Rate: 1, Per: time.Minute}),
+		WithKey(ByTenant()),
+		WithClock(c.Now),
+	))
+
+	acme := command.WithTenant(context.Background(), "acme")
+	globex := command.WithTenant(context.Background(), "globex")
+
+	if err := h.HandleCommand(acme, mocks.Command{ID: 1}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	var rlErr *Error
+	if err := h.HandleCommand(acme, mocks.Command{ID: 1}); !errors.As(err, &rlErr) || rlErr.Key != "acme" {
+		t.Error("the tenant should be rate limited:", err)
+	}
+	if err := h.HandleCommand(globex, mocks.Command{ID: 1}); err != nil {
+		t.Error("other tenants should not be rate limited:", err)
+	}
+}
+
+func TestMiddleware_MaxInFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	inner := &mocks.MockCommandHandler{BuFn: func(command.Command, command.Entity) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}}
+	h := command.UseHandlerMiddleware(inner, NewMiddleware(
+		WithLimit(mocks.CommandType, Limit{MaxInFlight: 1}),
+		WithKey(ByEntity()),
+	))
+
+	done := make(chan error, 2)
+	go func() { done <- h.HandleCommand(context.Background(), &entityCommand{id: 1}) }()
+	<-started
+
+	err := h.HandleCommand(context.Background(), &entityCommand{id: 1})
+	var rlErr *Error
+	if !errors.As(err, &rlErr) || rlErr.Key != "1" || rlErr.RetryAfter != 0 {
+		t.Error("there should be a rate limited error for the entity:", err)
+	}
+
+	go func() { done <- h.HandleCommand(context.Background(), &entityCommand{id: 2}) }()
+	<-started
+	release <- struct{}{}
+	release <- struct{}{}
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Error("there should be no error:", err)
+		}
+	}
+
+	go func() { done <- h.HandleCommand(context.Background(), &entityCommand{id: 1}) }()
+	<-started
+	release <- struct{}{}
+	if err := <-done; err != nil {
+		t.Error("the slot should be released once the command is handled:", err)
+	}
+}
+
+type entityCommand struct {
+	id int
+}
+
+func (c *entityCommand) CommandType() command.Type { return mocks.CommandType }
+func (c *entityCommand) Entity() command.Entity    { return &mocks.SimpleModel{ID: c.id} }
@@ -0,0 +1,39 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gapsquare/command"
+)
+
+// ErrRateLimited error when a command exceeds its rate or concurrency limit
+var ErrRateLimited = errors.New("Rate limited")
+
+// Error is a rate limit failure, it wraps ErrRateLimited
+type Error struct {
+	CommandType command.Type
+	// Key is the key returned by the KeyFunc, empty if limits apply per command type only
+	Key string
+	// RetryAfter is the time after which the command can be retried,
+	// zero if unknown, e.g. when the concurrency limit is reached
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%v: command %s", ErrRateLimited, e.CommandType)
+	if e.Key != "" {
+		msg += fmt.Sprintf(", key %s", e.Key)
+	}
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(", retry after %v", e.RetryAfter)
+	}
+	return msg
+}
+
+// Unwrap returns ErrRateLimited
+func (e *Error) Unwrap() error {
+	return ErrRateLimited
+}
@@ -0,0 +1,169 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gapsquare/command"
+)
+
+// Limit configures the limits of a command type
+type Limit struct {
+	// Rate is the number of commands allowed every Per, zero disables the rate limit
+	Rate int
+	Per  time.Duration
+	// Burst is the number of commands allowed at once, default Rate
+	Burst int
+	// MaxInFlight is the number of commands handled concurrently, zero disables the concurrency limit
+	MaxInFlight int
+}
+
+// KeyFunc returns the key the limits are applied to in addition to the command type,
+// e.g. an entity or a tenant. Commands with an empty key share the limits of their type
+type KeyFunc func(context.Context, command.Command) string
+
+// ByEntity is a KeyFunc applying the limits per entity
+func ByEntity() KeyFunc {
+	return func(_ context.Context, cmd command.Command) string {
+		if e := cmd.Entity(); e != nil {
+			return e.EntityID().String()
+		}
+		return ""
+	}
+}
+
+// ByTenant is a KeyFunc applying the limits per tenant of the context
+func ByTenant() KeyFunc {
+	return func(ctx context.Context, _ command.Command) string {
+		tenant, _ := command.TenantFromContext(ctx)
+		return string(tenant)
+	}
+}
+
+// Option configures the limiter of the middleware
+type Option func(*limiter)
+
+// WithLimit sets the limit of a command type, command types without limit are not limited
+func WithLimit(cmdType command.Type, limit Limit) Option {
+	return func(l *limiter) {
+		if limit.Burst <= 0 {
+			limit.Burst = limit.Rate
+		}
+		l.limits[cmdType] = limit
+	}
+}
+
+// WithKey sets the KeyFunc of the limits
+func WithKey(key KeyFunc) Option {
+	return func(l *limiter) {
+		l.key = key
+	}
+}
+
+// WithClock sets the function returning the current time, useful in tests
+func WithClock(now func() time.Time) Option {
+	return func(l *limiter) {
+		l.now = now
+	}
+}
+
+// sweepSize is the number of buckets above which refilled buckets are dropped
+const sweepSize = 1024
+
+type limitKey struct {
+	cmdType command.Type
+	key     string
+}
+
+// bucket is a token bucket
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type limiter struct {
+	mu     sync.Mutex
+	limits map[command.Type]Limit
+	key    KeyFunc
+	now    func() time.Time
+
+	buckets  map[limitKey]*bucket
+	inFlight map[limitKey]int
+}
+
+// acquire checks the limits of the command, release must be called once the command is handled
+func (l *limiter) acquire(ctx context.Context, cmd command.Command) (func(), error) {
+	limit, ok := l.limits[cmd.CommandType()]
+	if !ok {
+		return func() {}, nil
+	}
+
+	k := limitKey{cmdType: cmd.CommandType(), key: l.key(ctx, cmd)}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if limit.MaxInFlight > 0 && l.inFlight[k] >= limit.MaxInFlight {
+		return nil, &Error{CommandType: k.cmdType, Key: k.key}
+	}
+
+	if limit.Rate > 0 && limit.Per > 0 {
+		if retryAfter := l.take(k, limit); retryAfter > 0 {
+			return nil, &Error{CommandType: k.cmdType, Key: k.key, RetryAfter: retryAfter}
+		}
+	}
+
+	if limit.MaxInFlight <= 0 {
+		return func() {}, nil
+	}
+
+	l.inFlight[k]++
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if l.inFlight[k]--; l.inFlight[k] <= 0 {
+			delete(l.inFlight, k)
+		}
+	}, nil
+}
+
+// take takes a token from the bucket of the key, it returns the time until a token is available if it is empty
+func (l *limiter) take(k limitKey, limit Limit) time.Duration {
+	now := l.now()
+	perToken := float64(limit.Per) / float64(limit.Rate)
+
+	b, ok := l.buckets[k]
+	if !ok {
+		if len(l.buckets) >= sweepSize {
+			l.sweep(now)
+		}
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[k] = b
+	}
+
+	b.tokens += float64(now.Sub(b.last)) / perToken
+	if b.tokens > float64(limit.Burst) {
+		b.tokens = float64(limit.Burst)
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) * perToken)
+	}
+
+	b.tokens--
+	return 0
+}
+
+// sweep drops the buckets which are full again, they are recreated full when needed
+func (l *limiter) sweep(now time.Time) {
+	for k, b := range l.buckets {
+		limit := l.limits[k.cmdType]
+		perToken := float64(limit.Per) / float64(limit.Rate)
+		if b.tokens+float64(now.Sub(b.last))/perToken >= float64(limit.Burst) {
+			delete(l.buckets, k)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package timeout
+
+import (
+	"context"
+	"time"
+
+	"github.com/gapsquare/command"
+)
+
+// Option configures the timeouts of the middleware
+type Option func(*timeouts)
+
+// WithTimeout sets the timeout of a command type
+func WithTimeout(cmdType command.Type, d time.Duration) Option {
+	return func(t *timeouts) {
+		t.perType[cmdType] = d
+	}
+}
+
+// WithDefault sets the timeout of the command types without their own timeout
+func WithDefault(d time.Duration) Option {
+	return func(t *timeouts) {
+		t.fallback = d
+	}
+}
+
+type timeouts struct {
+	perType  map[command.Type]time.Duration
+	fallback time.Duration
+}
+
+func (t *timeouts) of(cmdType command.Type) time.Duration {
+	if d, ok := t.perType[cmdType]; ok {
+		return d
+	}
+	return t.fallback
+}
+
+// NewMiddleware returns a middleware which runs the handler with the timeout of the command type,
+// an earlier deadline of the context is kept. Handlers must honor the context, the middleware does
+// not abandon a running handler. Deadline errors are returned as a *command.TimeoutError of StageHandle
+func NewMiddleware(options ...Option) command.HandlerMiddleware {
+	t := &timeouts{perType: make(map[command.Type]time.Duration)}
+	for _, option := range options {
+		option(t)
+	}
+
+	return command.HandlerMiddleware(func(h command.Handler) command.Handler {
+		return command.HandlerFunc(func(ctx context.Context, cmd command.Command) error {
+			if d := t.of(cmd.CommandType()); d > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, d)
+				defer cancel()
+			}
+
+			if err := ctx.Err(); err != nil {
+				return command.StageTimeout(cmd.CommandType(), command.StageHandle, err)
+			}
+
+			// Immediate command execution.
+			return command.StageTimeout(cmd.CommandType(), command.StageHandle, h.HandleCommand(ctx, cmd))
+		})
+	})
+}
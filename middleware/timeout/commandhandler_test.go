@@ -0,0 +1,85 @@
+package timeout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gapsquare/command"
+
+	"github.com/gapsquare/command/mocks"
+)
+
+// waitCommand waits for the deadline of the context
+type waitCommand struct {
+	cmdType command.Type
+}
+
+func (c *waitCommand) CommandType() command.Type { return c.cmdType }
+func (c *waitCommand) Entity() command.Entity    { return nil }
+
+var waitHandler = command.HandlerFunc(func(ctx context.Context, cmd command.Command) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return nil
+	}
+	<-ctx.Done()
+	return ctx.Err()
+})
+
+func TestMiddleware_Timeout(t *testing.T) {
+	slow := command.Type("slow")
+	h := command.UseHandlerMiddleware(waitHandler, NewMiddleware(
+		WithTimeout(slow, time.Millisecond),
+	))
+
+	err := h.HandleCommand(context.Background(), &waitCommand{cmdType: slow})
+	var timeoutErr *command.TimeoutError
+	if !errors.As(err, &timeoutErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("there should be a timeout error:", err)
+	}
+	if timeoutErr.Stage != command.StageHandle || timeoutErr.CommandType != slow {
+		t.Error("the error should record the handle stage:", timeoutErr)
+	}
+
+	if err := h.HandleCommand(context.Background(), &waitCommand{cmdType: "other"}); err != nil {
+		t.Error("command types without timeout should have no deadline:", err)
+	}
+}
+
+func TestMiddleware_Default(t *testing.T) {
+	h := command.UseHandlerMiddleware(waitHandler, NewMiddleware(
+		WithDefault(time.Millisecond),
+		WithTimeout("unlimited", 0),
+	))
+
+	if err := h.HandleCommand(context.Background(), &waitCommand{cmdType: "other"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("the default timeout should apply:", err)
+	}
+	if err := h.HandleCommand(context.Background(), &waitCommand{cmdType: "unlimited"}); err != nil {
+		t.Error("a zero timeout should disable the default:", err)
+	}
+}
+
+func TestMiddleware_ExecuterTimeout(t *testing.T) {
+	slow := command.Type("timeout.slow")
+	command.RegisterCommandHandler(slow, command.UseHandlerMiddleware(waitHandler, NewMiddleware(
+		WithTimeout(slow, time.Hour),
+	)))
+	defer command.UnRegisterCommandHandler(slow)
+
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(mocks.MockCommandStore{}),
+		command.WithEventBus(&mocks.EventBus{}),
+		command.WithExecutionTimeout(time.Millisecond),
+	), &mocks.MockRepository{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ce.Execute(context.Background(), &waitCommand{cmdType: slow})
+	var timeoutErr *command.TimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Stage != command.StageHandle {
+		t.Error("the global maximum should apply to the handle stage:", err)
+	}
+}
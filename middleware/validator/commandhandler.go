@@ -7,16 +7,14 @@ import (
 )
 
 // NewMiddleware returns a new async handling middleware that validate commands
-// with its own validation method.
+// with their validate struct tags and their own validation method.
+// All violations are returned together as ValidationErrors, an error of the validation
+// method is returned unchanged if there are no struct tag violations
 func NewMiddleware() command.HandlerMiddleware {
 	return command.HandlerMiddleware(func(h command.Handler) command.Handler {
 		return command.HandlerFunc(func(ctx context.Context, cmd command.Command) error {
-			// Call the validation method if it exists
-			if c, ok := cmd.(Command); ok {
-				err := c.Validate()
-				if err != nil {
-					return err
-				}
+			if err := validate(ctx, cmd); err != nil {
+				return err
 			}
 
 			// Immediate command execution.
@@ -25,6 +23,43 @@ func NewMiddleware() command.HandlerMiddleware {
 	})
 }
 
+func validate(ctx context.Context, cmd command.Command) error {
+	errs := ValidateStruct(command.UnwrapCommand(cmd))
+
+	// Call the validation method of the outermost command having one, preferring the context aware one
+	var err error
+	for c := cmd; c != nil; c = unwrap(c) {
+		if v, ok := c.(ContextCommand); ok {
+			err = v.ValidateContext(ctx)
+			break
+		}
+		if v, ok := c.(Command); ok {
+			err = v.Validate()
+			break
+		}
+	}
+
+	if err != nil {
+		if len(errs) == 0 {
+			return err
+		}
+		errs = append(errs, asValidationErrors(err)...)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// unwrap returns the command wrapped by a command.Wrapper, nil if it is not one
+func unwrap(cmd command.Command) command.Command {
+	if w, ok := cmd.(command.Wrapper); ok {
+		return w.Unwrap()
+	}
+	return nil
+}
+
 // Command is a command with its own validation method
 type Command interface {
 	command.Command
@@ -32,16 +67,45 @@ type Command interface {
 	Validate() error
 }
 
+// ContextCommand is a command with its own context aware validation method,
+// it is preferred over Validate when a command implements both
+type ContextCommand interface {
+	command.Command
+	// ValidateContext returns the error when validating the command
+	ValidateContext(context.Context) error
+}
+
 // CommandWithValidation returns a wrapped command with a validation method
 func CommandWithValidation(cmd command.Command, v func() error) Command {
 	return &commandImp{Command: cmd, validate: v}
 }
 
+// CommandWithValidationContext returns a wrapped command with a context aware validation method
+func CommandWithValidationContext(cmd command.Command, v func(context.Context) error) ContextCommand {
+	return &commandImp{Command: cmd, validateContext: v}
+}
+
 type commandImp struct {
 	command.Command
-	validate func() error
+	validate        func() error
+	validateContext func(context.Context) error
+}
+
+// Unwrap implements the command.Wrapper interface
+func (c *commandImp) Unwrap() command.Command {
+	return c.Command
 }
 
 func (c *commandImp) Validate() error {
+	if c.validate == nil {
+		return c.validateContext(context.Background())
+	}
 	return c.validate()
 }
+
+func (c *commandImp) ValidateContext(ctx context.Context) error {
+	if c.validateContext == nil {
+		return c.validate()
+	}
+	return c.validateContext(ctx)
+}
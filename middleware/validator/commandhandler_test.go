@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/gapsquare/command"
+	"github.com/gapsquare/command/middleware/interceptors"
 
 	"github.com/gapsquare/command/mocks"
 )
@@ -39,3 +40,72 @@ func TestCommandHandler_WithValidationNoError(t *testing.T) {
 	}
 
 }
+
+type taggedCommand struct {
+	mocks.Command
+	Name string `json:"name" validate:"required"`
+}
+
+func TestCommandHandler_AggregatesTagAndCustomErrors(t *testing.T) {
+	inner := &mocks.MockCommandHandler{}
+	h := command.UseHandlerMiddleware(inner, NewMiddleware())
+
+	c := CommandWithValidation(&taggedCommand{}, func() error {
+		return ValidationErrors{{Field: "content", Code: CodeRequired, Message: "is required"}}
+	})
+	err := h.HandleCommand(context.Background(), c)
+
+	errs, ok := err.(ValidationErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatal("there should be two validation errors:", err)
+	}
+	if errs[0].Field != "name" || errs[1].Field != "content" {
+		t.Error("tag errors should come before custom errors:", errs)
+	}
+
+	c = CommandWithValidation(&taggedCommand{}, func() error { return errors.New("custom") })
+	err = h.HandleCommand(context.Background(), c)
+	if err.Error() != "name: is required; custom" {
+		t.Error("a plain custom error should be aggregated:", err)
+	}
+}
+
+func TestCommandHandler_WithTagValidationNoError(t *testing.T) {
+	inner := &mocks.MockCommandHandler{}
+	h := command.UseHandlerMiddleware(inner, NewMiddleware())
+	if err := h.HandleCommand(context.Background(), &taggedCommand{Name: "name"}); err != nil {
+		t.Error("there should be no error:", err)
+	}
+}
+
+func TestCommandHandler_UnwrapsOtherWrappers(t *testing.T) {
+	h := command.UseHandlerMiddleware(&mocks.MockCommandHandler{}, NewMiddleware())
+
+	e := errors.New("custom")
+	wrapped := interceptors.CommandInterceptBefore(CommandWithValidation(&taggedCommand{}, func() error { return e }),
+		func() error { return nil })
+	err := h.HandleCommand(context.Background(), wrapped)
+	if err == nil || err.Error() != "name: is required; custom" {
+		t.Error("the tags and the method of a command wrapped by another middleware should be validated:", err)
+	}
+}
+
+func TestCommandHandler_WithValidationContext(t *testing.T) {
+	inner := &mocks.MockCommandHandler{}
+	h := command.UseHandlerMiddleware(inner, NewMiddleware())
+
+	e := errors.New("user not allowed")
+	c := CommandWithValidationContext(&mocks.Command{ID: 1}, func(ctx context.Context) error {
+		if user, _ := mocks.ContextOne(ctx); user != "admin" {
+			return e
+		}
+		return nil
+	})
+
+	if err := h.HandleCommand(context.Background(), c); err != e {
+		t.Error("there should be an error:", e)
+	}
+	if err := h.HandleCommand(mocks.WithContextOne(context.Background(), "admin"), c); err != nil {
+		t.Error("there should be no error:", err)
+	}
+}
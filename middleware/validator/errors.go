@@ -0,0 +1,59 @@
+package validator
+
+import (
+	"errors"
+	"strings"
+)
+
+// Validation error codes
+const (
+	CodeRequired = "required"
+	CodeMin      = "min"
+	CodeMax      = "max"
+	CodeRegex    = "regex"
+	CodeEnum     = "enum"
+	CodeInvalid  = "invalid"
+)
+
+// FieldError is a single validation violation
+type FieldError struct {
+	// Field is the path of the field, e.g. address.lines[0], empty for command level violations
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors is the list of all violations found when validating a command
+type ValidationErrors []FieldError
+
+// Error implements the error interface
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// asValidationErrors converts an error returned by a Validate method to ValidationErrors
+func asValidationErrors(err error) ValidationErrors {
+	var errs ValidationErrors
+	if errors.As(err, &errs) {
+		return errs
+	}
+
+	var fe FieldError
+	if errors.As(err, &fe) {
+		return ValidationErrors{fe}
+	}
+
+	return ValidationErrors{{Code: CodeInvalid, Message: err.Error()}}
+}
@@ -0,0 +1,235 @@
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// tagName is the struct tag holding the validation rules
+const tagName = "validate"
+
+var regexps sync.Map
+
+// ValidateStruct validates the fields of v, a struct or a pointer to a struct, with their validate tags,
+// e.g. `validate:"required,min=1,max=10"`. It returns nil if there are no violations.
+//
+// Supported rules:
+//
+//	required     the field must not be its zero value
+//	min=n, max=n bounds of a number, or of the length of a string, slice or map
+//	regex=expr   a string must match expr, expr can not contain commas
+//	enum=a|b|c   the field, formatted with fmt, must be one of the values
+//
+// Nested structs, pointers to structs and slices and maps of structs are validated recursively,
+// a struct referenced several times by pointers is validated once.
+// Field paths use the json name of the field if it has one
+func ValidateStruct(v interface{}) ValidationErrors {
+	var errs ValidationErrors
+	w := walker{visited: map[visit]bool{}, errs: &errs}
+	w.validateValue(reflect.ValueOf(v), "")
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// visit identifies a struct reached through a pointer
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// walker walks a value, the visited pointers guard against cycles
+type walker struct {
+	visited map[visit]bool
+	errs    *ValidationErrors
+}
+
+func (w walker) validateValue(v reflect.Value, path string) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Ptr {
+			key := visit{v.Pointer(), v.Type()}
+			if w.visited[key] {
+				return
+			}
+			w.visited[key] = true
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		w.validateStruct(v, path)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			w.validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, k := range keys {
+			w.validateValue(v.MapIndex(k), fmt.Sprintf("%s[%v]", path, k))
+		}
+	}
+}
+
+func (w walker) validateStruct(v reflect.Value, path string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		field := fieldPath(path, f)
+		if tag, ok := f.Tag.Lookup(tagName); ok && tag != "-" {
+			validateRules(v.Field(i), field, tag, w.errs)
+		}
+
+		w.validateValue(v.Field(i), field)
+	}
+}
+
+func validateRules(v reflect.Value, field, tag string, errs *ValidationErrors) {
+	// the rules apply to the value of pointer fields, a nil pointer only fails required
+	value := v
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, arg = rule[:i], rule[i+1:]
+		}
+
+		if name != "required" && value.Kind() == reflect.Ptr {
+			continue
+		}
+
+		var fe *FieldError
+		switch name {
+		case "required":
+			fe = checkRequired(v)
+		case "min":
+			fe = checkBound(value, arg, CodeMin)
+		case "max":
+			fe = checkBound(value, arg, CodeMax)
+		case "regex":
+			fe = checkRegex(value, arg)
+		case "enum":
+			fe = checkEnum(value, arg)
+		default:
+			fe = &FieldError{Code: CodeInvalid, Message: fmt.Sprintf("unknown validation rule %q", name)}
+		}
+
+		if fe != nil {
+			fe.Field = field
+			*errs = append(*errs, *fe)
+		}
+	}
+}
+
+func checkRequired(v reflect.Value) *FieldError {
+	if v.IsZero() {
+		return &FieldError{Code: CodeRequired, Message: "is required"}
+	}
+	return nil
+}
+
+func checkBound(v reflect.Value, arg, code string) *FieldError {
+	bound, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return &FieldError{Code: CodeInvalid, Message: fmt.Sprintf("invalid %s bound %q", code, arg)}
+	}
+
+	var actual float64
+	subject := "must be"
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		actual = v.Float()
+	case reflect.String:
+		actual = float64(len([]rune(v.String())))
+		subject = "length must be"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		actual = float64(v.Len())
+		subject = "length must be"
+	default:
+		return &FieldError{Code: CodeInvalid, Message: fmt.Sprintf("%s is not supported for %s", code, v.Kind())}
+	}
+
+	if code == CodeMin && actual < bound {
+		return &FieldError{Code: CodeMin, Message: fmt.Sprintf("%s at least %s", subject, arg)}
+	}
+	if code == CodeMax && actual > bound {
+		return &FieldError{Code: CodeMax, Message: fmt.Sprintf("%s at most %s", subject, arg)}
+	}
+	return nil
+}
+
+func checkRegex(v reflect.Value, expr string) *FieldError {
+	if v.Kind() != reflect.String {
+		return &FieldError{Code: CodeInvalid, Message: fmt.Sprintf("regex is not supported for %s", v.Kind())}
+	}
+
+	re, err := compile(expr)
+	if err != nil {
+		return &FieldError{Code: CodeInvalid, Message: fmt.Sprintf("invalid regex %q", expr)}
+	}
+
+	if !re.MatchString(v.String()) {
+		return &FieldError{Code: CodeRegex, Message: fmt.Sprintf("must match %s", expr)}
+	}
+	return nil
+}
+
+func checkEnum(v reflect.Value, arg string) *FieldError {
+	value := fmt.Sprint(v.Interface())
+	values := strings.Split(arg, "|")
+	for _, allowed := range values {
+		if value == allowed {
+			return nil
+		}
+	}
+	return &FieldError{Code: CodeEnum, Message: fmt.Sprintf("must be one of %s", strings.Join(values, ", "))}
+}
+
+func compile(expr string) (*regexp.Regexp, error) {
+	if re, ok := regexps.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexps.Store(expr, re)
+	return re, nil
+}
+
+func fieldPath(parent string, f reflect.StructField) string {
+	name := f.Name
+	if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+		name = tag
+	}
+
+	if f.Anonymous && f.Tag.Get("json") == "" {
+		return parent
+	}
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
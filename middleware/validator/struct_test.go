@@ -0,0 +1,112 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+)
+
+type address struct {
+	Line    string `json:"line" validate:"required"`
+	Country string `json:"country" validate:"enum=GB|FR"`
+}
+
+type createOrder struct {
+	Name     string    `json:"name" validate:"required,min=2,max=5"`
+	Code     string    `json:"code" validate:"regex=^[A-Z]{3}$"`
+	Quantity int       `json:"quantity" validate:"min=1,max=10"`
+	Tags     []string  `validate:"max=2"`
+	Address  *address  `json:"address" validate:"required"`
+	Previous []address `json:"previous"`
+	ignored  string    `validate:"required"`
+}
+
+func TestValidateStruct(t *testing.T) {
+	cmd := &createOrder{
+		Name:     "abcdef",
+		Code:     "ab",
+		Quantity: 0,
+		Tags:     []string{"a", "b", "c"},
+		Address:  &address{Country: "DE"},
+		Previous: []address{{Line: "l", Country: "GB"}, {Country: "FR"}},
+	}
+
+	expected := ValidationErrors{
+		{Field: "name", Code: CodeMax, Message: "length must be at most 5"},
+		{Field: "code", Code: CodeRegex, Message: "must match ^[A-Z]{3}$"},
+		{Field: "quantity", Code: CodeMin, Message: "must be at least 1"},
+		{Field: "Tags", Code: CodeMax, Message: "length must be at most 2"},
+		{Field: "address.line", Code: CodeRequired, Message: "is required"},
+		{Field: "address.country", Code: CodeEnum, Message: "must be one of GB, FR"},
+		{Field: "previous[1].line", Code: CodeRequired, Message: "is required"},
+	}
+
+	if errs := ValidateStruct(cmd); !reflect.DeepEqual(errs, expected) {
+		t.Errorf("the validation errors should be correct:\n%#v\n%#v", errs, expected)
+	}
+}
+
+func TestValidateStructValid(t *testing.T) {
+	cmd := createOrder{Name: "ab", Code: "ABC", Quantity: 10, Address: &address{Line: "l", Country: "GB"}}
+	if errs := ValidateStruct(cmd); errs != nil {
+		t.Error("there should be no error:", errs)
+	}
+
+	if errs := ValidateStruct(createOrder{}); len(errs) != 5 {
+		t.Error("all rules should apply to the zero value:", errs)
+	}
+}
+
+type updateOrder struct {
+	Status   *string `json:"status" validate:"enum=new|paid"`
+	Code     *string `json:"code" validate:"regex=^[A-Z]{3}$"`
+	Quantity *int    `json:"quantity" validate:"required,min=1"`
+}
+
+func TestValidateStructPointers(t *testing.T) {
+	status, code, quantity := "paid", "ABC", 2
+	if errs := ValidateStruct(updateOrder{Status: &status, Code: &code, Quantity: &quantity}); errs != nil {
+		t.Error("the rules should apply to the pointed values:", errs)
+	}
+
+	expected := ValidationErrors{{Field: "quantity", Code: CodeRequired, Message: "is required"}}
+	if errs := ValidateStruct(updateOrder{}); !reflect.DeepEqual(errs, expected) {
+		t.Error("nil pointers should only fail required:", errs)
+	}
+
+	status, code, quantity = "shipped", "ab", 0
+	expected = ValidationErrors{
+		{Field: "status", Code: CodeEnum, Message: "must be one of new, paid"},
+		{Field: "code", Code: CodeRegex, Message: "must match ^[A-Z]{3}$"},
+		{Field: "quantity", Code: CodeMin, Message: "must be at least 1"},
+	}
+	if errs := ValidateStruct(updateOrder{Status: &status, Code: &code, Quantity: &quantity}); !reflect.DeepEqual(errs, expected) {
+		t.Errorf("the pointed values should be validated:\n%#v\n%#v", errs, expected)
+	}
+}
+
+type node struct {
+	Name  string           `json:"name" validate:"required"`
+	Next  *node            `json:"next"`
+	Items map[string]*node `json:"items"`
+}
+
+func TestValidateStructCyclesAndMaps(t *testing.T) {
+	n := &node{Name: "a"}
+	n.Next = n
+	n.Items = map[string]*node{"b": {}, "self": n}
+
+	expected := ValidationErrors{{Field: "items[b].name", Code: CodeRequired, Message: "is required"}}
+	if errs := ValidateStruct(n); !reflect.DeepEqual(errs, expected) {
+		t.Error("the map values should be validated once per pointer:", errs)
+	}
+}
+
+func TestValidationErrorsMessage(t *testing.T) {
+	errs := ValidationErrors{
+		{Field: "name", Code: CodeRequired, Message: "is required"},
+		{Code: CodeInvalid, Message: "order is closed"},
+	}
+	if errs.Error() != "name: is required; order is closed" {
+		t.Error("the error message should be correct:", errs.Error())
+	}
+}
@@ -0,0 +1,82 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/goevent"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var MockEventCommandType = command.Type("mock.event.command")
+
+var _ = command.WithEvents(&MockEventCommand{})
+
+type MockEventCommand struct {
+	MockSimpleCommand
+}
+
+func (c *MockEventCommand) CommandType() command.Type {
+	return MockEventCommandType
+}
+
+func (c *MockEventCommand) Events(ctx context.Context) goevent.Events {
+	return goevent.Events{goevent.NewEvent(Topic, &EventData{Content: c.Name})}
+}
+
+func TestExecuterInterceptors(t *testing.T) {
+	command.RegisterCommandHandler(MockEventCommandType, &MockCommandHandler{})
+	defer command.UnRegisterCommandHandler(MockEventCommandType)
+
+	repository := &MockRepository{}
+	bus := &EventBus{}
+	var calls []string
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(mockCommandStore),
+		command.WithEventBus(bus),
+		command.WithAfterSaveInterceptor(func(ctx context.Context, cmd command.Command) error {
+			assert.True(t, repository.SaveCalled)
+			assert.Empty(t, bus.Events)
+			calls = append(calls, "save")
+			return nil
+		}),
+		command.WithAfterPublishInterceptor(func(ctx context.Context, cmd command.Command, events goevent.Events) error {
+			assert.Len(t, events, 1)
+			assert.Equal(t, bus.Events, events)
+			calls = append(calls, "publish")
+			return nil
+		}),
+	), repository)
+	if !assert.Nil(t, err) {
+		t.Fatal(err)
+	}
+
+	cmd := &MockEventCommand{MockSimpleCommand{ID: 1, Name: "created", entity: &SimpleModel{ID: 1}}}
+	assert.Nil(t, ce.Execute(context.Background(), cmd))
+	assert.Equal(t, []string{"save", "publish"}, calls)
+}
+
+func TestExecuterAfterSaveInterceptorErrorStopsPublish(t *testing.T) {
+	command.RegisterCommandHandler(MockEventCommandType, &MockCommandHandler{})
+	defer command.UnRegisterCommandHandler(MockEventCommandType)
+
+	bus := &EventBus{}
+	interceptorErr := errors.New("interceptor failed")
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(mockCommandStore),
+		command.WithEventBus(bus),
+		command.WithAfterSaveInterceptor(func(ctx context.Context, cmd command.Command) error {
+			return interceptorErr
+		}),
+	), &MockRepository{})
+	if !assert.Nil(t, err) {
+		t.Fatal(err)
+	}
+
+	cmd := &MockEventCommand{MockSimpleCommand{ID: 1, entity: &SimpleModel{ID: 1}}}
+	assert.Equal(t, interceptorErr, ce.Execute(context.Background(), cmd))
+	assert.Empty(t, bus.Events)
+}
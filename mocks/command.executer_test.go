@@ -181,7 +181,7 @@ var _ = command.Versionable(&MockVersionableCommand{})
 
 type MockVersionableCommand struct {
 	MockSimpleCommand
-	Ver int `json: "version"`
+	Ver int `json:"version"`
 }
 
 func (c *MockVersionableCommand) CommandType() command.Type {
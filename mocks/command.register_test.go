@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"testing"
+
+	"github.com/gapsquare/command"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterCommand(t *testing.T) {
+	assert.NotNil(t, command.RegisterCommand("", func() command.Command { return &MockSimpleCommand{} }))
+	assert.NotNil(t, command.RegisterCommand(CommandType, nil))
+
+	assert.Nil(t, command.RegisterCommand(CommandType, func() command.Command { return &MockSimpleCommand{} }))
+	assert.NotNil(t, command.RegisterCommand(CommandType, func() command.Command { return &MockSimpleCommand{} }))
+
+	cmd, err := command.CreateCommand(CommandType)
+	assert.Nil(t, err)
+	assert.IsType(t, &MockSimpleCommand{}, cmd)
+
+	assert.Nil(t, command.UnRegisterCommand(CommandType))
+	assert.NotNil(t, command.UnRegisterCommand(CommandType))
+
+	_, err = command.CreateCommand(CommandType)
+	assert.Equal(t, command.ErrCommandNotRegistered, err)
+}
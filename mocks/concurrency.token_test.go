@@ -0,0 +1,119 @@
+package mocks
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gapsquare/command"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var MockETagCommandType = command.Type("mock.etag.command")
+var etagCommandHandler = &MockCommandHandler{}
+
+func init() {
+	command.RegisterCommandHandler(MockETagCommandType, etagCommandHandler)
+}
+
+var _ = command.ConcurrencyTokenable(&MockETagCommand{})
+
+type MockETagCommand struct {
+	MockSimpleCommand
+	ETag string `json:"etag"`
+}
+
+func (c *MockETagCommand) CommandType() command.Type {
+	return MockETagCommandType
+}
+
+func (c *MockETagCommand) ConcurrencyToken() command.ConcurrencyToken {
+	return c.ETag
+}
+
+func newETagExecuter(t *testing.T, repository command.ReadWriteRepository, options ...command.Configuration) command.Executer {
+	options = append([]command.Configuration{
+		command.WithCommandStore(mockCommandStore),
+		command.WithEventBus(eventBus),
+	}, options...)
+	ce, err := command.NewExecuter(command.BuildConfiguration(options...), repository)
+	if !assert.Nil(t, err) {
+		t.Fatal(err)
+	}
+	return ce
+}
+
+func TestRepositoryAssignedVersionsRequiresAssigningRepository(t *testing.T) {
+	repository := struct{ command.ReadWriteRepository }{&MockRepository{}}
+	_, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(mockCommandStore),
+		command.WithEventBus(eventBus),
+		command.WithRepositoryAssignedVersions(),
+	), repository)
+	assert.NotNil(t, err)
+	assert.Equal(t, "repository does not assign versions", err.Error())
+}
+
+func TestConcurrencyTokenMismatch(t *testing.T) {
+	etagCommandHandler.BuFn = nil
+	repository := &MockRepository{Entity: &MockETagModel{ID: 1, ETag: "v1"}}
+	ce := newETagExecuter(t, repository)
+
+	cmd := &MockETagCommand{MockSimpleCommand: MockSimpleCommand{ID: 1, entity: &MockETagModel{ID: 1}}, ETag: "v0"}
+	err := ce.Execute(context.Background(), cmd)
+	assert.Equal(t, command.ErrVersionMismatched, err)
+	assert.False(t, repository.SaveCalled)
+}
+
+func TestConcurrencyTokenCustomComparer(t *testing.T) {
+	etagCommandHandler.BuFn = nil
+	repository := &MockRepository{Entity: &MockETagModel{ID: 1, ETag: "ABC"}}
+	ce := newETagExecuter(t, repository, command.WithTokenComparer(func(expected, actual command.ConcurrencyToken) bool {
+		return strings.EqualFold(expected.(string), actual.(string))
+	}))
+
+	cmd := &MockETagCommand{MockSimpleCommand: MockSimpleCommand{ID: 1, entity: &MockETagModel{ID: 1}}, ETag: "abc"}
+	assert.Nil(t, ce.Execute(context.Background(), cmd))
+	assert.True(t, repository.SaveCalled)
+}
+
+func TestRepositoryAssignedVersions(t *testing.T) {
+	etagCommandHandler.BuFn = nil
+	repository := &MockRepository{
+		Entity: &MockETagModel{ID: 1, ETag: "v1"},
+		AssignToken: func(e command.Entity) {
+			e.(*MockETagModel).ETag = "v2"
+		},
+	}
+	ce := newETagExecuter(t, repository, command.WithRepositoryAssignedVersions())
+
+	cmd := &MockETagCommand{MockSimpleCommand: MockSimpleCommand{ID: 1, entity: &MockETagModel{ID: 1}}, ETag: "v1"}
+	assert.Nil(t, ce.Execute(context.Background(), cmd))
+	assert.Equal(t, "v2", repository.Entity.(*MockETagModel).ETag)
+}
+
+func TestRepositoryAssignedVersionsDoesNotIncrement(t *testing.T) {
+	repository := &MockRepository{Entity: &MockVersionableModel{ID: 1, VersionInt: 1}}
+	ce := newETagExecuter(t, repository, command.WithRepositoryAssignedVersions())
+	command.RegisterCommandHandler(MockVersionableCommandType, commandHandler)
+
+	cmd := &MockVersionableCommand{MockSimpleCommand: MockSimpleCommand{ID: 1, entity: &MockVersionableModel{}}, Ver: 1}
+	assert.Nil(t, ce.Execute(context.Background(), cmd))
+	assert.Equal(t, 1, repository.Entity.(*MockVersionableModel).VersionInt)
+}
+
+func TestRepositoryAssignedVersionsConflict(t *testing.T) {
+	repository := &MockRepository{Entity: &MockETagModel{ID: 1, ETag: "v1"}}
+	ce := newETagExecuter(t, repository, command.WithRepositoryAssignedVersions())
+
+	// simulate another writer committing while the command is handled
+	etagCommandHandler.BuFn = func(command.Command, command.Entity) error {
+		repository.Entity = &MockETagModel{ID: 1, ETag: "v2"}
+		return nil
+	}
+	defer func() { etagCommandHandler.BuFn = nil }()
+
+	cmd := &MockETagCommand{MockSimpleCommand: MockSimpleCommand{ID: 1, entity: &MockETagModel{ID: 1}}, ETag: "v1"}
+	assert.Equal(t, command.ErrConcurrencyConflict, ce.Execute(context.Background(), cmd))
+}
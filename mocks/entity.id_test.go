@@ -0,0 +1,45 @@
+package mocks
+
+import (
+	"testing"
+
+	"github.com/gapsquare/command"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntEntityID(t *testing.T) {
+	id := command.IntEntityID(42)
+	assert.Equal(t, command.EntityID("42"), id)
+	assert.Equal(t, "42", id.String())
+	assert.False(t, id.IsEmpty())
+	assert.True(t, command.EntityID("").IsEmpty())
+}
+
+func TestCompositeEntityID(t *testing.T) {
+	id := command.CompositeEntityID("tenant:1", "order", "100%")
+	assert.Equal(t, command.EntityID("tenant%3A1:order:100%25"), id)
+	assert.Equal(t, []string{"tenant:1", "order", "100%"}, id.Parts())
+
+	assert.Equal(t, []string{"single"}, command.EntityID("single").Parts())
+}
+
+func TestUUIDEntityID(t *testing.T) {
+	id := command.NewUUIDEntityID()
+	assert.Len(t, id.String(), 36)
+	assert.Equal(t, byte('4'), id.String()[14])
+	assert.NotEqual(t, id, command.NewUUIDEntityID())
+
+	parsed, err := command.ParseUUIDEntityID(id.String())
+	assert.Nil(t, err)
+	assert.Equal(t, id, parsed)
+
+	parsed, err = command.ParseUUIDEntityID("6BA7B810-9DAD-11D1-80B4-00C04FD430C8")
+	assert.Nil(t, err)
+	assert.Equal(t, command.EntityID("6ba7b810-9dad-11d1-80b4-00c04fd430c8"), parsed)
+
+	for _, invalid := range []string{"", "6ba7b810", "6ba7b810x9dad-11d1-80b4-00c04fd430c8", "6ba7b810-9dad-11d1-80b4-00c04fd430cz"} {
+		_, err = command.ParseUUIDEntityID(invalid)
+		assert.Equal(t, command.ErrInvalidUUID, err, invalid)
+	}
+}
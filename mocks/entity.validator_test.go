@@ -0,0 +1,76 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gapsquare/command"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var MockShipCommandType = command.Type("mock.ship.command")
+var MockArchiveCommandType = command.Type("mock.archive.command")
+
+var errOrderCancelled = errors.New("order is cancelled")
+
+var _ = command.EntityValidator(&MockShipCommand{})
+
+type MockShipCommand struct {
+	MockSimpleCommand
+}
+
+func (c *MockShipCommand) CommandType() command.Type {
+	return MockShipCommandType
+}
+
+func (c *MockShipCommand) ValidateAgainst(e command.Entity) error {
+	if e.(*SimpleModel).Content == "cancelled" {
+		return errOrderCancelled
+	}
+	return nil
+}
+
+type MockArchiveCommand struct {
+	MockShipCommand
+}
+
+func (c *MockArchiveCommand) CommandType() command.Type {
+	return MockArchiveCommandType
+}
+
+func TestEntityValidatorPreconditionFails(t *testing.T) {
+	handled := false
+	handler := &MockCommandHandler{BuFn: func(command.Command, command.Entity) error {
+		handled = true
+		return nil
+	}}
+	command.RegisterCommandHandler(MockShipCommandType, handler)
+	command.RegisterCommandHandler(MockArchiveCommandType, command.NewDestructiveHandler(handler,
+		func(context.Context, command.Command) error { return nil }))
+	defer command.UnRegisterCommandHandler(MockShipCommandType)
+	defer command.UnRegisterCommandHandler(MockArchiveCommandType)
+
+	repository := &MockRepository{Entity: &SimpleModel{ID: 1, Content: "cancelled"}}
+	ce := newETagExecuter(t, repository)
+
+	for _, cmd := range []command.Command{
+		&MockShipCommand{MockSimpleCommand{ID: 1, entity: &SimpleModel{ID: 1}}},
+		&MockArchiveCommand{MockShipCommand{MockSimpleCommand{ID: 1, entity: &SimpleModel{ID: 1}}}},
+	} {
+		err := ce.Execute(context.Background(), cmd)
+
+		var precondition *command.PreconditionError
+		if assert.True(t, errors.As(err, &precondition), cmd.CommandType()) {
+			assert.Equal(t, cmd.CommandType(), precondition.CommandType)
+			assert.True(t, errors.Is(err, errOrderCancelled))
+		}
+		assert.False(t, handled)
+	}
+
+	repository.Entity = &SimpleModel{ID: 1, Content: "paid"}
+	err := ce.Execute(context.Background(), &MockShipCommand{MockSimpleCommand{ID: 1, entity: &SimpleModel{ID: 1}}})
+	assert.Nil(t, err)
+	assert.True(t, handled)
+}
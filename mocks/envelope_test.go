@@ -0,0 +1,34 @@
+package mocks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gapsquare/command"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvelope(t *testing.T) {
+	assert.Nil(t, command.RegisterCommand(MockSimpleCommandType, func() command.Command { return &MockSimpleCommand{} }))
+	defer command.UnRegisterCommand(MockSimpleCommandType)
+
+	ctx := command.WithTenant(command.WithCorrelationID(context.Background(), "corr-1"), "acme")
+	envelope, err := command.NewEnvelope(ctx, &MockSimpleCommand{ID: 1, Name: "created"})
+	if !assert.Nil(t, err) {
+		t.Fatal(err)
+	}
+	assert.Equal(t, MockSimpleCommandType, envelope.Type)
+	assert.Equal(t, command.Metadata{command.MetadataCorrelationID: "corr-1", command.MetadataTenantID: "acme"}, envelope.Metadata)
+
+	cmd, err := envelope.Command()
+	assert.Nil(t, err)
+	assert.Equal(t, &MockSimpleCommand{ID: 1, Name: "created"}, cmd)
+
+	restored := envelope.Context(context.Background())
+	id, _ := command.CorrelationID(restored)
+	tenant, _ := command.TenantFromContext(restored)
+	assert.Equal(t, "corr-1", id)
+	assert.Equal(t, command.TenantID("acme"), tenant)
+	assert.NotContains(t, command.MetadataFromContext(restored), command.MetadataTenantID)
+}
@@ -0,0 +1,48 @@
+package mocks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gapsquare/command"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataContext(t *testing.T) {
+	ctx := command.WithCorrelationID(context.Background(), "corr-1")
+	child := command.WithMetadata(ctx, "source", "api")
+
+	id, ok := command.CorrelationID(child)
+	assert.True(t, ok)
+	assert.Equal(t, "corr-1", id)
+	assert.Equal(t, command.Metadata{command.MetadataCorrelationID: "corr-1", "source": "api"}, command.MetadataFromContext(child))
+	assert.Len(t, command.MetadataFromContext(ctx), 1, "the parent metadata should not be modified")
+
+	_, ok = command.CorrelationID(context.Background())
+	assert.False(t, ok)
+}
+
+func TestExecuterStampsMetadataOnEvents(t *testing.T) {
+	command.RegisterCommandHandler(MockEventCommandType, &MockCommandHandler{})
+	defer command.UnRegisterCommandHandler(MockEventCommandType)
+
+	bus := &EventBus{}
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(mockCommandStore),
+		command.WithEventBus(bus),
+	), &MockRepository{})
+	if !assert.Nil(t, err) {
+		t.Fatal(err)
+	}
+
+	ctx := command.WithCorrelationID(context.Background(), "corr-1")
+	cmd := &MockEventCommand{MockSimpleCommand{ID: 1, Name: "created", entity: &SimpleModel{ID: 1}}}
+	assert.Nil(t, ce.Execute(ctx, cmd))
+
+	if assert.Len(t, bus.Events, 1) {
+		id, ok := command.EventCorrelationID(bus.Events[0])
+		assert.True(t, ok)
+		assert.Equal(t, "corr-1", id)
+	}
+}
@@ -34,7 +34,7 @@ type EventData struct {
 
 // MockVersionableModel is a mocked read model, useful in testing
 type MockVersionableModel struct {
-	ID         int       `json:"id" 	bson:"_id"`
+	ID         int       `json:"id" bson:"_id"`
 	VersionInt int       `json:"version" bson:"version"`
 	Content    string    `json:"content" bson:"content"`
 	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
@@ -45,7 +45,7 @@ var _ = command.Versionable(&MockVersionableModel{})
 var _ = command.EntityVersionable(&MockVersionableModel{})
 
 // EntityID implements the EntityID method of the command.Entity
-func (m *MockVersionableModel) EntityID() command.EntityID { return command.EntityID(m.ID) }
+func (m *MockVersionableModel) EntityID() command.EntityID { return command.IntEntityID(m.ID) }
 
 // Version implements Version method of the command.Versionable interface
 func (m *MockVersionableModel) Version() command.VersionType { return command.VersionType(m.VersionInt) }
@@ -56,14 +56,45 @@ func (m *MockVersionableModel) IncrementVersion() {
 
 // SimpleModel is a mocked read model for a simple model without version
 type SimpleModel struct {
-	ID      int    `json:"id" 	bson:"_id"`
+	ID      int    `json:"id" bson:"_id"`
 	Content string `json:"content" bson:"content"`
 }
 
 var _ = command.Entity(&SimpleModel{})
 
 // EntityID implements the EntityID method of the command.Entity
-func (m *SimpleModel) EntityID() command.EntityID { return command.EntityID(m.ID) }
+func (m *SimpleModel) EntityID() command.EntityID { return command.IntEntityID(m.ID) }
+
+// MockETagModel is a mocked read model which uses an ETag as concurrency token
+type MockETagModel struct {
+	ID      int    `json:"id" bson:"_id"`
+	ETag    string `json:"etag" bson:"etag"`
+	Content string `json:"content" bson:"content"`
+}
+
+var _ = command.Entity(&MockETagModel{})
+var _ = command.ConcurrencyTokenable(&MockETagModel{})
+
+// EntityID implements the EntityID method of the command.Entity
+func (m *MockETagModel) EntityID() command.EntityID { return command.IntEntityID(m.ID) }
+
+// ConcurrencyToken implements the ConcurrencyToken method of the command.ConcurrencyTokenable
+func (m *MockETagModel) ConcurrencyToken() command.ConcurrencyToken { return m.ETag }
+
+// MockTenantModel is a mocked read model belonging to a tenant
+type MockTenantModel struct {
+	ID      int    `json:"id" bson:"_id"`
+	Tenant  string `json:"tenant" bson:"tenant"`
+	Content string `json:"content" bson:"content"`
+}
+
+var _ = command.TenantOwned(&MockTenantModel{})
+
+// EntityID implements the EntityID method of the command.Entity
+func (m *MockTenantModel) EntityID() command.EntityID { return command.IntEntityID(m.ID) }
+
+// TenantID implements the TenantID method of the command.TenantOwned
+func (m *MockTenantModel) TenantID() command.TenantID { return command.TenantID(m.Tenant) }
 
 // EventHandler is a mocked command.EventHandler, useful in testing.
 type EventHandler struct {
@@ -150,9 +181,12 @@ type MockRepository struct {
 	Entity                               command.Entity
 	LoadErr, SaveErr, FindErr            error
 	FindCalled, SaveCalled, RemoveCalled bool
+	// AssignToken assigns a new concurrency token to the entity in SaveAssignVersion
+	AssignToken func(command.Entity)
 }
 
 var _ = command.ReadWriteRepository(&MockRepository{})
+var _ = command.VersionAssigningRepository(&MockRepository{})
 
 // Find implements the Find method of command.ReadRepository
 func (r *MockRepository) Find(entity command.Entity) error {
@@ -193,6 +227,26 @@ func (r *MockRepository) Save(entity command.Entity) error {
 	return nil
 }
 
+// SaveAssignVersion implements the SaveAssignVersion method of command.VersionAssigningRepository
+func (r *MockRepository) SaveAssignVersion(entity command.Entity, expected command.ConcurrencyToken, compare command.TokenComparer) error {
+	r.SaveCalled = true
+	if r.SaveErr != nil {
+		return r.SaveErr
+	}
+
+	if r.Entity != nil {
+		if stored, _ := command.ConcurrencyTokenOf(r.Entity); !compare(expected, stored) {
+			return command.ErrConcurrencyConflict
+		}
+	}
+
+	if r.AssignToken != nil {
+		r.AssignToken(entity)
+	}
+	r.Entity = entity
+	return nil
+}
+
 var _ = command.Command(Command{})
 
 //Command is a mocked Command usefull in testing
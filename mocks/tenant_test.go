@@ -0,0 +1,100 @@
+package mocks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/command/repository/memrepo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var MockTenantCommandType = command.Type("mock.tenant.command")
+
+type MockTenantCommand struct {
+	MockEventCommand
+}
+
+func (c *MockTenantCommand) CommandType() command.Type {
+	return MockTenantCommandType
+}
+
+type tenantStore struct {
+	tenants []command.TenantID
+}
+
+func (s *tenantStore) Save(command.Command, command.WriteRepository) error {
+	s.tenants = append(s.tenants, "")
+	return nil
+}
+
+func (s *tenantStore) SaveForTenant(tenant command.TenantID, cmd command.Command, r command.WriteRepository) error {
+	s.tenants = append(s.tenants, tenant)
+	return nil
+}
+
+func newTenantCommand(id int, content string) *MockTenantCommand {
+	return &MockTenantCommand{MockEventCommand{MockSimpleCommand{ID: id, Name: content, entity: &MockTenantModel{ID: id}}}}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	command.RegisterCommandHandler(MockTenantCommandType, &MockCommandHandler{BuFn: func(c command.Command, e command.Entity) error {
+		m := e.(*MockTenantModel)
+		if m.Tenant == "" {
+			m.Tenant = "acme"
+		}
+		m.Content = c.(*MockTenantCommand).Name
+		return nil
+	}})
+	defer command.UnRegisterCommandHandler(MockTenantCommandType)
+
+	repository := memrepo.NewRepository()
+	store := &tenantStore{}
+	bus := &EventBus{}
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(store),
+		command.WithEventBus(bus),
+		command.WithTenantIsolation(),
+	), repository)
+	if !assert.Nil(t, err) {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, command.ErrTenantMissing, ce.Execute(context.Background(), newTenantCommand(1, "created")))
+
+	acme := command.WithTenant(context.Background(), "acme")
+	assert.Nil(t, ce.Execute(acme, newTenantCommand(1, "created")))
+	assert.Equal(t, []command.TenantID{"acme"}, store.tenants)
+
+	if assert.Len(t, bus.Events, 1) {
+		tenant, ok := command.EventTenant(bus.Events[0])
+		assert.True(t, ok)
+		assert.Equal(t, command.TenantID("acme"), tenant)
+		assert.Equal(t, Topic, bus.Events[0].Topic())
+	}
+
+	found := &MockTenantModel{ID: 1}
+	assert.Nil(t, repository.ForTenant("acme").Find(found))
+	assert.Equal(t, "created", found.Content)
+	assert.Equal(t, command.ErrEntityNotFound, repository.ForTenant("globex").Find(&MockTenantModel{ID: 1}))
+}
+
+func TestTenantMismatch(t *testing.T) {
+	command.RegisterCommandHandler(MockTenantCommandType, &MockCommandHandler{})
+	defer command.UnRegisterCommandHandler(MockTenantCommandType)
+
+	// a repository which is not tenant aware returns the entity of another tenant
+	repository := &MockRepository{Entity: &MockTenantModel{ID: 1, Tenant: "globex"}}
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(mockCommandStore),
+		command.WithEventBus(&EventBus{}),
+	), repository)
+	if !assert.Nil(t, err) {
+		t.Fatal(err)
+	}
+
+	acme := command.WithTenant(context.Background(), "acme")
+	assert.Equal(t, command.ErrTenantMismatch, ce.Execute(acme, newTenantCommand(1, "updated")))
+	assert.False(t, repository.SaveCalled)
+}
@@ -0,0 +1,40 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gapsquare/command"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutionTimeoutStages(t *testing.T) {
+	command.RegisterCommandHandler(CommandType, &MockCommandHandler{BuFn: func(command.Command, command.Entity) error {
+		// a handler ignoring the context
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	}})
+	defer command.UnRegisterCommandHandler(CommandType)
+
+	repository := &MockRepository{}
+	ce := newETagExecuter(t, repository, command.WithExecutionTimeout(time.Millisecond))
+
+	err := ce.Execute(context.Background(), Command{ID: 1})
+	var timeoutErr *command.TimeoutError
+	if assert.True(t, errors.As(err, &timeoutErr), err) {
+		assert.Equal(t, command.StageStoreSave, timeoutErr.Stage)
+		assert.Equal(t, CommandType, timeoutErr.CommandType)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	}
+	assert.False(t, repository.SaveCalled, "the entity should not be saved after the deadline")
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	err = ce.Execute(ctx, Command{ID: 1})
+	if assert.True(t, errors.As(err, &timeoutErr), err) {
+		assert.Equal(t, command.StageHandlerLookup, timeoutErr.Stage, "the first stage should time out")
+	}
+}
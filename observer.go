@@ -0,0 +1,44 @@
+package command
+
+import "context"
+
+// Observer observes the executions and their stages, e.g. to record metrics or traces.
+// The returned function is called with the result of the execution or of the stage,
+// the returned context is used by the execution or the stage
+type Observer interface {
+	// ObserveExecution is called when the execution of a command starts
+	ObserveExecution(context.Context, Command) (context.Context, func(error))
+	// ObserveStage is called when a stage of the execution starts, StagePublish is observed once per event
+	ObserveStage(context.Context, Command, Stage) (context.Context, func(error))
+}
+
+// observers calls a list of observers, in order, and their result functions in reverse order
+type observers []Observer
+
+func (o observers) ObserveExecution(ctx context.Context, cmd Command) (context.Context, func(error)) {
+	return o.observe(ctx, func(ctx context.Context, ob Observer) (context.Context, func(error)) {
+		return ob.ObserveExecution(ctx, cmd)
+	})
+}
+
+func (o observers) ObserveStage(ctx context.Context, cmd Command, stage Stage) (context.Context, func(error)) {
+	return o.observe(ctx, func(ctx context.Context, ob Observer) (context.Context, func(error)) {
+		return ob.ObserveStage(ctx, cmd, stage)
+	})
+}
+
+func (o observers) observe(ctx context.Context, fn func(context.Context, Observer) (context.Context, func(error))) (context.Context, func(error)) {
+	if len(o) == 0 {
+		return ctx, func(error) {}
+	}
+
+	finishes := make([]func(error), len(o))
+	for i, ob := range o {
+		ctx, finishes[i] = fn(ctx, ob)
+	}
+	return ctx, func(err error) {
+		for i := len(finishes) - 1; i >= 0; i-- {
+			finishes[i](err)
+		}
+	}
+}
@@ -1,5 +1,14 @@
 package command
 
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrEntityNotFound error returned by ReadRepository.Find when the entity does not exist,
+// constructive commands treat a not found entity as a new one
+var ErrEntityNotFound = errors.New("Entity not found")
+
 // WriteRepository is a write repository for entities
 type WriteRepository interface {
 	Save(Entity) error
@@ -16,3 +25,38 @@ type ReadWriteRepository interface {
 	ReadRepository
 	WriteRepository
 }
+
+// VersionAssigningRepository is a repository which assigns new versions itself when saving.
+// The partitions of a TenantAwareRepository and the transactions of a TransactionalRepository
+// must implement it too
+type VersionAssigningRepository interface {
+	WriteRepository
+
+	// SaveAssignVersion saves the entity only if compare matches expected with its stored concurrency token,
+	// assigning the new token to the entity. It returns ErrConcurrencyConflict if the stored token changed
+	SaveAssignVersion(entity Entity, expected ConcurrencyToken, compare TokenComparer) error
+}
+
+// ConditionalWriteRepository is a repository which enforces the version check atomically when saving
+type ConditionalWriteRepository interface {
+	WriteRepository
+
+	// SaveIfVersion saves the entity only if its stored version equals expected, a not stored entity has version 0.
+	// It returns ErrConcurrencyConflict if the stored version changed
+	SaveIfVersion(entity Entity, expected VersionType) error
+}
+
+// EntityEncoder serializes the entities of the repositories storing them as bytes
+type EntityEncoder interface {
+	Marshal(Entity) ([]byte, error)
+	Unmarshal([]byte, Entity) error
+}
+
+// JSONEntityEncoder is an EntityEncoder using encoding/json
+type JSONEntityEncoder struct{}
+
+// Marshal implements the Marshal method of EntityEncoder
+func (JSONEntityEncoder) Marshal(e Entity) ([]byte, error) { return json.Marshal(e) }
+
+// Unmarshal implements the Unmarshal method of EntityEncoder
+func (JSONEntityEncoder) Unmarshal(b []byte, e Entity) error { return json.Unmarshal(b, e) }
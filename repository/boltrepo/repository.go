@@ -0,0 +1,244 @@
+package boltrepo
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"reflect"
+
+	"github.com/gapsquare/command"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	entitiesBucket = []byte("entities")
+	commandsBucket = []byte("commands")
+)
+
+var errNotBoltRepository = errors.New("repository is not a boltrepo repository")
+var errCorruptedRecord = errors.New("corrupted entity record")
+
+// BucketNamer is an entity which provides its bucket name, other entities use their type name
+type BucketNamer interface {
+	BucketName() string
+}
+
+// Option configures a Repository
+type Option func(*Repository)
+
+// WithEncoder sets the entity encoder, default command.JSONEntityEncoder
+func WithEncoder(encoder command.EntityEncoder) Option {
+	return func(r *Repository) {
+		r.encoder = encoder
+	}
+}
+
+// Repository is a command.ReadWriteRepository on a bbolt database.
+// Entities are stored per type together with their version, which is used by SaveIfVersion
+type Repository struct {
+	db      *bolt.DB
+	encoder command.EntityEncoder
+}
+
+var _ = command.TransactionalRepository(&Repository{})
+var _ = command.ConditionalWriteRepository(&Repository{})
+
+// NewRepository creates a Repository on db
+func NewRepository(db *bolt.DB, options ...Option) *Repository {
+	r := &Repository{db: db, encoder: command.JSONEntityEncoder{}}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// Find implements the Find method of command.ReadRepository
+func (r *Repository) Find(entity command.Entity) error {
+	return r.db.View(func(tx *bolt.Tx) error {
+		return find(tx, r.encoder, entity)
+	})
+}
+
+// Save implements the Save method of command.WriteRepository
+func (r *Repository) Save(entity command.Entity) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return save(tx, r.encoder, entity, nil)
+	})
+}
+
+// SaveIfVersion implements the SaveIfVersion method of command.ConditionalWriteRepository
+func (r *Repository) SaveIfVersion(entity command.Entity, expected command.VersionType) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return save(tx, r.encoder, entity, &expected)
+	})
+}
+
+// Remove implements the Remove method of command.WriteRepository
+func (r *Repository) Remove(entity command.Entity) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return remove(tx, entity)
+	})
+}
+
+// BeginTransaction implements the BeginTransaction method of command.TransactionalRepository.
+// bbolt allows a single writable transaction, the Repository must not be written while it is open.
+// Waiting for the transaction stops with ctx, the transaction is then rolled back as soon as it is acquired
+func (r *Repository) BeginTransaction(ctx context.Context) (command.Transaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type begun struct {
+		tx  *bolt.Tx
+		err error
+	}
+	c := make(chan begun, 1)
+	go func() {
+		tx, err := r.db.Begin(true)
+		c <- begun{tx, err}
+	}()
+
+	select {
+	case b := <-c:
+		if b.err != nil {
+			return nil, b.err
+		}
+		return &Tx{tx: b.tx, encoder: r.encoder}, nil
+	case <-ctx.Done():
+		go func() {
+			if b := <-c; b.err == nil {
+				b.tx.Rollback()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// Tx is a Repository bound to a writable bbolt transaction
+type Tx struct {
+	tx      *bolt.Tx
+	encoder command.EntityEncoder
+}
+
+var _ = command.Transaction(&Tx{})
+var _ = command.ConditionalWriteRepository(&Tx{})
+
+// BoltTx returns the underlying transaction, so other buckets can be written in the same transaction
+func (t *Tx) BoltTx() *bolt.Tx {
+	return t.tx
+}
+
+// Find implements the Find method of command.ReadRepository
+func (t *Tx) Find(entity command.Entity) error {
+	return find(t.tx, t.encoder, entity)
+}
+
+// Save implements the Save method of command.WriteRepository
+func (t *Tx) Save(entity command.Entity) error {
+	return save(t.tx, t.encoder, entity, nil)
+}
+
+// SaveIfVersion implements the SaveIfVersion method of command.ConditionalWriteRepository
+func (t *Tx) SaveIfVersion(entity command.Entity, expected command.VersionType) error {
+	return save(t.tx, t.encoder, entity, &expected)
+}
+
+// Remove implements the Remove method of command.WriteRepository
+func (t *Tx) Remove(entity command.Entity) error {
+	return remove(t.tx, entity)
+}
+
+// Commit implements the Commit method of command.Transaction
+func (t *Tx) Commit() error {
+	return t.tx.Commit()
+}
+
+// Rollback implements the Rollback method of command.Transaction
+func (t *Tx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+func find(tx *bolt.Tx, encoder command.EntityEncoder, entity command.Entity) error {
+	b := entityBucket(tx, entity)
+	if b == nil {
+		return command.ErrEntityNotFound
+	}
+
+	value := b.Get([]byte(entity.EntityID()))
+	if value == nil {
+		return command.ErrEntityNotFound
+	}
+	if len(value) < 8 {
+		return errCorruptedRecord
+	}
+
+	return encoder.Unmarshal(value[8:], entity)
+}
+
+// save stores the entity, if expected is not nil only when the stored version equals expected
+func save(tx *bolt.Tx, encoder command.EntityEncoder, entity command.Entity, expected *command.VersionType) error {
+	root, err := tx.CreateBucketIfNotExists(entitiesBucket)
+	if err != nil {
+		return err
+	}
+	b, err := root.CreateBucketIfNotExists([]byte(bucketName(entity)))
+	if err != nil {
+		return err
+	}
+
+	key := []byte(entity.EntityID())
+	if expected != nil {
+		var current command.VersionType
+		if value := b.Get(key); len(value) >= 8 {
+			current = command.VersionType(binary.BigEndian.Uint64(value))
+		}
+		if current != *expected {
+			return command.ErrConcurrencyConflict
+		}
+	}
+
+	data, err := encoder.Marshal(entity)
+	if err != nil {
+		return err
+	}
+
+	var version command.VersionType
+	if v, ok := entity.(command.Versionable); ok {
+		version = v.Version()
+	}
+
+	value := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint64(value, uint64(version))
+	return b.Put(key, append(value, data...))
+}
+
+func remove(tx *bolt.Tx, entity command.Entity) error {
+	b := entityBucket(tx, entity)
+	key := []byte(entity.EntityID())
+	if b == nil || b.Get(key) == nil {
+		return command.ErrEntityNotFound
+	}
+
+	return b.Delete(key)
+}
+
+func entityBucket(tx *bolt.Tx, entity command.Entity) *bolt.Bucket {
+	root := tx.Bucket(entitiesBucket)
+	if root == nil {
+		return nil
+	}
+	return root.Bucket([]byte(bucketName(entity)))
+}
+
+func bucketName(entity command.Entity) string {
+	if n, ok := entity.(BucketNamer); ok {
+		return n.BucketName()
+	}
+
+	t := reflect.TypeOf(entity)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.String()
+}
@@ -0,0 +1,170 @@
+package boltrepo
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/command/mocks"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	renameType = command.Type("boltrepo.rename")
+	deleteType = command.Type("boltrepo.delete")
+)
+
+type renameCommand struct {
+	ID      int
+	Ver     command.VersionType
+	Content string
+}
+
+func (c *renameCommand) CommandType() command.Type { return renameType }
+func (c *renameCommand) Entity() command.Entity {
+	return &mocks.MockVersionableModel{ID: c.ID}
+}
+func (c *renameCommand) Version() command.VersionType { return c.Ver }
+
+type deleteCommand struct {
+	ID int
+}
+
+func (c *deleteCommand) CommandType() command.Type { return deleteType }
+func (c *deleteCommand) Entity() command.Entity    { return &mocks.SimpleModel{ID: c.ID} }
+
+var errDelete error
+
+func init() {
+	command.RegisterCommandHandler(renameType, &mocks.MockCommandHandler{})
+	command.RegisterCommandHandler(deleteType, command.NewDestructiveHandler(&mocks.MockCommandHandler{},
+		func(ctx context.Context, cmd command.Command) error {
+			if errDelete != nil {
+				return errDelete
+			}
+			tx, _ := command.TransactionFromContext(ctx)
+			return tx.Remove(cmd.Entity())
+		}))
+}
+
+func openDB(t *testing.T) *bolt.DB {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestRepositoryFindSaveRemove(t *testing.T) {
+	r := NewRepository(openDB(t))
+
+	if err := r.Find(&mocks.SimpleModel{ID: 1}); err != command.ErrEntityNotFound {
+		t.Error("there should be a not found error:", err)
+	}
+
+	if err := r.Save(&mocks.SimpleModel{ID: 1, Content: "content"}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	found := &mocks.SimpleModel{ID: 1}
+	if err := r.Find(found); err != nil || found.Content != "content" {
+		t.Error("the entity should be found:", found, err)
+	}
+
+	if err := r.Find(&mocks.MockVersionableModel{ID: 1}); err != command.ErrEntityNotFound {
+		t.Error("entities of other types should not be found:", err)
+	}
+
+	if err := r.Remove(found); err != nil {
+		t.Error("there should be no error:", err)
+	}
+	if err := r.Remove(found); err != command.ErrEntityNotFound {
+		t.Error("there should be a not found error:", err)
+	}
+}
+
+func TestRepositorySaveIfVersion(t *testing.T) {
+	r := NewRepository(openDB(t))
+
+	if err := r.SaveIfVersion(&mocks.MockVersionableModel{ID: 1, VersionInt: 1}, 0); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if err := r.SaveIfVersion(&mocks.MockVersionableModel{ID: 1, VersionInt: 2}, 0); err != command.ErrConcurrencyConflict {
+		t.Error("there should be a concurrency conflict:", err)
+	}
+	if err := r.SaveIfVersion(&mocks.MockVersionableModel{ID: 1, VersionInt: 2}, 1); err != nil {
+		t.Error("there should be no error:", err)
+	}
+}
+
+func TestBeginTransactionStopsWithContext(t *testing.T) {
+	r := NewRepository(openDB(t))
+	tx, err := r.BeginTransaction(context.Background())
+	if err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := r.BeginTransaction(ctx); err != context.DeadlineExceeded {
+		t.Error("waiting for the write transaction should stop with the context:", err)
+	}
+
+	tx.Rollback()
+	tx, err = r.BeginTransaction(context.Background())
+	if err != nil {
+		t.Fatal("the abandoned transaction should be released:", err)
+	}
+	tx.Rollback()
+}
+
+func TestExecuterSharesTransactionWithCommandStore(t *testing.T) {
+	r := NewRepository(openDB(t))
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(CommandStore{}),
+		command.WithEventBus(&mocks.EventBus{}),
+	), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ce.Execute(context.Background(), &renameCommand{ID: 1, Content: "a"}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if err := ce.Execute(context.Background(), &renameCommand{ID: 1, Ver: 1, Content: "b"}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	found := &mocks.MockVersionableModel{ID: 1}
+	if err := r.Find(found); err != nil || found.VersionInt != 2 {
+		t.Error("the entity should be saved with version 2:", found, err)
+	}
+
+	r.Save(&mocks.SimpleModel{ID: 2})
+	errDelete = errors.New("can not delete")
+	defer func() { errDelete = nil }()
+	if err := ce.Execute(context.Background(), &deleteCommand{ID: 2}); err != errDelete {
+		t.Error("there should be a delete error:", err)
+	}
+
+	errDelete = nil
+	if err := ce.Execute(context.Background(), &deleteCommand{ID: 2}); err != nil {
+		t.Error("there should be no error:", err)
+	}
+	if err := r.Find(&mocks.SimpleModel{ID: 2}); err != command.ErrEntityNotFound {
+		t.Error("the entity should be removed:", err)
+	}
+
+	commands, err := r.Commands()
+	if err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if len(commands) != 3 || commands[0].Type != renameType || commands[2].Type != deleteType || commands[2].ID != "2" {
+		t.Error("only the committed commands should be stored:", commands)
+	}
+}
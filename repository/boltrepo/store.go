@@ -0,0 +1,89 @@
+package boltrepo
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/gapsquare/command"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// StoredCommand is a command saved by the CommandStore
+type StoredCommand struct {
+	Type command.Type     `json:"type"`
+	ID   command.EntityID `json:"entity_id,omitempty"`
+	Data json.RawMessage  `json:"data"`
+}
+
+// CommandStore is a command.Store which saves commands in the same bbolt database as the Repository,
+// within the executer's transaction when the repository is transactional
+type CommandStore struct{}
+
+var _ = command.Store(CommandStore{})
+
+// Save implements the Save method of command.Store, the repository must be a Repository or a Tx
+func (CommandStore) Save(cmd command.Command, repository command.WriteRepository) error {
+	switch r := repository.(type) {
+	case *Tx:
+		return saveCommand(r.tx, cmd)
+	case *Repository:
+		return r.db.Update(func(tx *bolt.Tx) error {
+			return saveCommand(tx, cmd)
+		})
+	default:
+		return errNotBoltRepository
+	}
+}
+
+// Commands returns the saved commands in the order they were saved
+func (r *Repository) Commands() ([]StoredCommand, error) {
+	var commands []StoredCommand
+	err := r.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(commandsBucket)
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(_, v []byte) error {
+			var c StoredCommand
+			if err := json.Unmarshal(v, &c); err != nil {
+				return err
+			}
+			commands = append(commands, c)
+			return nil
+		})
+	})
+	return commands, err
+}
+
+func saveCommand(tx *bolt.Tx, cmd command.Command) error {
+	b, err := tx.CreateBucketIfNotExists(commandsBucket)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+
+	stored := StoredCommand{Type: cmd.CommandType(), Data: data}
+	if e := cmd.Entity(); e != nil {
+		stored.ID = e.EntityID()
+	}
+
+	value, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return b.Put(key, value)
+}
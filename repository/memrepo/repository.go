@@ -0,0 +1,256 @@
+package memrepo
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/gapsquare/command"
+)
+
+var errNotPointer = errors.New("entity must be a pointer")
+
+var errNoTokenAssigner = errors.New("entity is not versionable and no token assigner is set")
+
+// key identifies a stored entity by its tenant, type and id
+type key struct {
+	tenant     command.TenantID
+	entityType reflect.Type
+	id         command.EntityID
+}
+
+// storage holds the entities of all tenants
+type storage struct {
+	mu       sync.RWMutex
+	entities map[key]reflect.Value
+	assign   func(command.Entity) error
+}
+
+// Option configures a Repository
+type Option func(*storage)
+
+// WithTokenAssigner sets the function assigning a new concurrency token to an entity in SaveAssignVersion,
+// by default the version of a command.EntityVersionable is incremented
+func WithTokenAssigner(assign func(command.Entity) error) Option {
+	return func(s *storage) {
+		s.assign = assign
+	}
+}
+
+// incrementVersion is the default token assigner
+func incrementVersion(entity command.Entity) error {
+	v, ok := entity.(command.EntityVersionable)
+	if !ok {
+		return errNoTokenAssigner
+	}
+	v.IncrementVersion()
+	return nil
+}
+
+// Repository is an in-memory command.ReadWriteRepository, safe for concurrent use.
+// Entities are keyed by their type and EntityID and deep copied on read and write,
+// so callers never share memory with the stored entities.
+// It is partitioned by tenant, ForTenant returns the partition of a tenant
+type Repository struct {
+	*storage
+	tenant command.TenantID
+}
+
+var _ = command.ReadWriteRepository(&Repository{})
+var _ = command.ConditionalWriteRepository(&Repository{})
+var _ = command.TenantAwareRepository(&Repository{})
+var _ = command.VersionAssigningRepository(&Repository{})
+
+// NewRepository creates an empty in-memory repository
+func NewRepository(options ...Option) *Repository {
+	s := &storage{entities: make(map[key]reflect.Value), assign: incrementVersion}
+	for _, option := range options {
+		option(s)
+	}
+	return &Repository{storage: s}
+}
+
+// ForTenant implements the ForTenant method of command.TenantAwareRepository,
+// the returned repository shares the storage but only sees the entities of the tenant
+func (r *Repository) ForTenant(tenant command.TenantID) command.ReadWriteRepository {
+	return &Repository{storage: r.storage, tenant: tenant}
+}
+
+// Find implements the Find method of command.ReadRepository
+func (r *Repository) Find(entity command.Entity) error {
+	k, v, err := r.keyOf(entity)
+	if err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.entities[k]
+	if !ok {
+		return command.ErrEntityNotFound
+	}
+
+	v.Elem().Set(deepCopy(stored.Elem()))
+	return nil
+}
+
+// Save implements the Save method of command.WriteRepository
+func (r *Repository) Save(entity command.Entity) error {
+	k, v, err := r.keyOf(entity)
+	if err != nil {
+		return err
+	}
+
+	stored := deepCopy(v)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entities[k] = stored
+	return nil
+}
+
+// SaveIfVersion implements the SaveIfVersion method of command.ConditionalWriteRepository
+func (r *Repository) SaveIfVersion(entity command.Entity, expected command.VersionType) error {
+	k, v, err := r.keyOf(entity)
+	if err != nil {
+		return err
+	}
+
+	stored := deepCopy(v)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.version(k) != expected {
+		return command.ErrConcurrencyConflict
+	}
+
+	r.entities[k] = stored
+	return nil
+}
+
+// SaveAssignVersion implements the SaveAssignVersion method of command.VersionAssigningRepository,
+// a not stored entity has the concurrency token of a zero entity
+func (r *Repository) SaveAssignVersion(entity command.Entity, expected command.ConcurrencyToken, compare command.TokenComparer) error {
+	k, v, err := r.keyOf(entity)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.entities[k]
+	if !ok {
+		stored = reflect.New(k.entityType)
+	}
+	if actual, _ := command.ConcurrencyTokenOf(stored.Interface()); !compare(expected, actual) {
+		return command.ErrConcurrencyConflict
+	}
+
+	if err := r.assign(entity); err != nil {
+		return err
+	}
+
+	r.entities[k] = deepCopy(v)
+	return nil
+}
+
+// Remove implements the Remove method of command.WriteRepository
+func (r *Repository) Remove(entity command.Entity) error {
+	k, _, err := r.keyOf(entity)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.entities[k]; !ok {
+		return command.ErrEntityNotFound
+	}
+
+	delete(r.entities, k)
+	return nil
+}
+
+// List returns copies of all stored entities with the same type as of, ordered by EntityID
+func (r *Repository) List(of command.Entity) ([]command.Entity, error) {
+	return r.Query(of, nil)
+}
+
+// Query returns copies of the stored entities with the same type as of for which match returns true,
+// ordered by EntityID. A nil match returns all entities of the type
+func (r *Repository) Query(of command.Entity, match func(command.Entity) bool) ([]command.Entity, error) {
+	k, _, err := r.keyOf(of)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	copies := []command.Entity{}
+	for stored, v := range r.entities {
+		if stored.tenant == k.tenant && stored.entityType == k.entityType {
+			copies = append(copies, deepCopy(v).Interface().(command.Entity))
+		}
+	}
+	r.mu.RUnlock()
+
+	// match runs without holding the lock, so it may use the repository
+	entities := []command.Entity{}
+	for _, e := range copies {
+		if match == nil || match(e) {
+			entities = append(entities, e)
+		}
+	}
+
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].EntityID() < entities[j].EntityID()
+	})
+	return entities, nil
+}
+
+// Count returns the number of stored entities with the same type as of
+func (r *Repository) Count(of command.Entity) (int, error) {
+	k, _, err := r.keyOf(of)
+	if err != nil {
+		return 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for stored := range r.entities {
+		if stored.tenant == k.tenant && stored.entityType == k.entityType {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// version returns the stored version of an entity, a not stored entity has version 0
+func (r *Repository) version(k key) command.VersionType {
+	stored, ok := r.entities[k]
+	if !ok {
+		return 0
+	}
+
+	if v, ok := stored.Interface().(command.Versionable); ok {
+		return v.Version()
+	}
+	return 0
+}
+
+// keyOf returns the key of an entity and its pointer value
+func (r *Repository) keyOf(entity command.Entity) (key, reflect.Value, error) {
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return key{}, reflect.Value{}, errNotPointer
+	}
+
+	return key{tenant: r.tenant, entityType: v.Type().Elem(), id: entity.EntityID()}, v, nil
+}
@@ -0,0 +1,368 @@
+package memrepo
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/command/mocks"
+)
+
+const updateCommandType = command.Type("memrepo.update")
+
+type updateCommand struct {
+	Ver     command.VersionType
+	Content string
+	entity  *mocks.MockVersionableModel
+}
+
+func (c *updateCommand) CommandType() command.Type    { return updateCommandType }
+func (c *updateCommand) Entity() command.Entity       { return c.entity }
+func (c *updateCommand) Version() command.VersionType { return c.Ver }
+
+func newUpdateCommand(id int, version command.VersionType, content string) *updateCommand {
+	return &updateCommand{Ver: version, Content: content, entity: &mocks.MockVersionableModel{ID: id}}
+}
+
+var updateHandler = &mocks.MockCommandHandler{}
+
+func init() {
+	updateHandler.BuFn = func(c command.Command, e command.Entity) error {
+		e.(*mocks.MockVersionableModel).Content = c.(*updateCommand).Content
+		return nil
+	}
+	command.RegisterCommandHandler(updateCommandType, updateHandler)
+}
+
+func newExecuter(t *testing.T, r command.ReadWriteRepository) command.Executer {
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(&mocks.MockCommandStore{}),
+		command.WithEventBus(&mocks.EventBus{}),
+	), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ce
+}
+
+func TestRepositoryFindSaveRemove(t *testing.T) {
+	r := NewRepository()
+
+	if err := r.Find(&mocks.SimpleModel{ID: 1}); err != command.ErrEntityNotFound {
+		t.Error("there should be a not found error:", err)
+	}
+
+	model := &mocks.SimpleModel{ID: 1, Content: "content"}
+	if err := r.Save(model); err != nil {
+		t.Error("there should be no error:", err)
+	}
+	model.Content = "changed after save"
+
+	found := &mocks.SimpleModel{ID: 1}
+	if err := r.Find(found); err != nil {
+		t.Error("there should be no error:", err)
+	}
+	if found.Content != "content" {
+		t.Error("the stored entity should not be aliased:", found.Content)
+	}
+
+	if err := r.Remove(found); err != nil {
+		t.Error("there should be no error:", err)
+	}
+	if err := r.Remove(found); err != command.ErrEntityNotFound {
+		t.Error("there should be a not found error:", err)
+	}
+}
+
+func TestRepositorySaveIfVersion(t *testing.T) {
+	r := NewRepository()
+
+	if err := r.SaveIfVersion(&mocks.MockVersionableModel{ID: 1, VersionInt: 1}, 1); err != command.ErrConcurrencyConflict {
+		t.Error("there should be a conflict for a not stored entity:", err)
+	}
+	if err := r.SaveIfVersion(&mocks.MockVersionableModel{ID: 1, VersionInt: 1}, 0); err != nil {
+		t.Error("there should be no error:", err)
+	}
+	if err := r.SaveIfVersion(&mocks.MockVersionableModel{ID: 1, VersionInt: 2}, 0); err != command.ErrConcurrencyConflict {
+		t.Error("there should be a conflict:", err)
+	}
+	if err := r.SaveIfVersion(&mocks.MockVersionableModel{ID: 1, VersionInt: 2}, 1); err != nil {
+		t.Error("there should be no error:", err)
+	}
+}
+
+func TestExecuterUsesConditionalSave(t *testing.T) {
+	r := NewRepository()
+	ce := newExecuter(t, r)
+
+	if err := ce.Execute(context.Background(), newUpdateCommand(1, 0, "created")); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	found := &mocks.MockVersionableModel{ID: 1}
+	if err := r.Find(found); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if found.VersionInt != 1 || found.Content != "created" {
+		t.Error("the entity should be created with version 1:", found)
+	}
+
+	// another writer commits between the version check and the save
+	defer func(fn func(command.Command, command.Entity) error) { updateHandler.BuFn = fn }(updateHandler.BuFn)
+	updateHandler.BuFn = func(c command.Command, e command.Entity) error {
+		return r.Save(&mocks.MockVersionableModel{ID: 1, VersionInt: 2, Content: "concurrent"})
+	}
+
+	if err := ce.Execute(context.Background(), newUpdateCommand(1, 1, "updated")); err != command.ErrConcurrencyConflict {
+		t.Error("there should be a concurrency conflict:", err)
+	}
+}
+
+func TestExecuterChecksLoadedVersion(t *testing.T) {
+	r := NewRepository()
+	ce := newExecuter(t, r)
+	if err := ce.Execute(context.Background(), newUpdateCommand(1, 0, "created")); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	// another writer commits version 2 and the handler sets the version it expects
+	defer func(fn func(command.Command, command.Entity) error) { updateHandler.BuFn = fn }(updateHandler.BuFn)
+	updateHandler.BuFn = func(c command.Command, e command.Entity) error {
+		e.(*mocks.MockVersionableModel).VersionInt = 2
+		return r.Save(&mocks.MockVersionableModel{ID: 1, VersionInt: 2, Content: "concurrent"})
+	}
+
+	cmd := newUpdateCommand(1, 1, "updated")
+	if err := ce.Execute(context.Background(), cmd); err != command.ErrConcurrencyConflict {
+		t.Error("the save should be checked against the loaded version:", err)
+	}
+	if cmd.entity.VersionInt != 2 {
+		t.Error("the entity should not get the next version when the save fails:", cmd.entity.VersionInt)
+	}
+
+	found := &mocks.MockVersionableModel{ID: 1}
+	if err := r.Find(found); err != nil || found.Content != "concurrent" {
+		t.Error("the concurrent write should be kept:", found, err)
+	}
+}
+
+const renameCommandType = command.Type("memrepo.rename")
+
+type renameCommand struct {
+	ID      int
+	Content string
+}
+
+func (c *renameCommand) CommandType() command.Type { return renameCommandType }
+func (c *renameCommand) Entity() command.Entity    { return &mocks.SimpleModel{ID: c.ID} }
+
+func TestExecuterCreatesNotFoundEntity(t *testing.T) {
+	command.RegisterCommandHandler(renameCommandType, &mocks.MockCommandHandler{})
+	defer command.UnRegisterCommandHandler(renameCommandType)
+
+	r := NewRepository()
+	ce := newExecuter(t, r)
+
+	// a command which is not versionable creates the entity of an unknown id
+	if err := ce.Execute(context.Background(), &renameCommand{ID: 7, Content: "renamed"}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if err := r.Find(&mocks.SimpleModel{ID: 7}); err != nil {
+		t.Error("the entity should be created:", err)
+	}
+
+	// a versionable command expects version 0 for an unknown id
+	if err := ce.Execute(context.Background(), newUpdateCommand(8, 1, "updated")); err != command.ErrVersionMismatched {
+		t.Error("there should be a version mismatch:", err)
+	}
+	if err := r.Find(&mocks.MockVersionableModel{ID: 8}); err != command.ErrEntityNotFound {
+		t.Error("the entity should not be created:", err)
+	}
+}
+
+func TestExecuterRepositoryAssignedVersions(t *testing.T) {
+	r := NewRepository()
+	compared := 0
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(&mocks.MockCommandStore{}),
+		command.WithEventBus(&mocks.EventBus{}),
+		command.WithRepositoryAssignedVersions(),
+		command.WithTokenComparer(func(expected, actual command.ConcurrencyToken) bool {
+			compared++
+			return command.DefaultTokenComparer(expected, actual)
+		}),
+	), r)
+	if err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	ctx := command.WithTenant(context.Background(), "acme")
+	if err := ce.Execute(ctx, newUpdateCommand(1, 0, "created")); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if err := ce.Execute(ctx, newUpdateCommand(1, 1, "updated")); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	found := &mocks.MockVersionableModel{ID: 1}
+	if err := r.ForTenant("acme").Find(found); err != nil || found.VersionInt != 2 || found.Content != "updated" {
+		t.Error("the repository should assign the versions:", found, err)
+	}
+
+	// another writer commits between the version check and the save
+	defer func(fn func(command.Command, command.Entity) error) { updateHandler.BuFn = fn }(updateHandler.BuFn)
+	updateHandler.BuFn = func(c command.Command, e command.Entity) error {
+		return r.ForTenant("acme").Save(&mocks.MockVersionableModel{ID: 1, VersionInt: 3})
+	}
+	if err := ce.Execute(ctx, newUpdateCommand(1, 2, "conflict")); err != command.ErrConcurrencyConflict {
+		t.Error("there should be a concurrency conflict:", err)
+	}
+	if compared != 6 {
+		t.Error("the configured comparer should be used by the executer and the repository:", compared)
+	}
+}
+
+func TestRepositorySaveAssignVersionWithoutAssigner(t *testing.T) {
+	r := NewRepository()
+	if err := r.SaveAssignVersion(&mocks.SimpleModel{ID: 1}, nil, command.DefaultTokenComparer); err != errNoTokenAssigner {
+		t.Error("an entity which is not versionable needs a token assigner:", err)
+	}
+
+	r = NewRepository(WithTokenAssigner(func(command.Entity) error { return nil }))
+	if err := r.SaveAssignVersion(&mocks.SimpleModel{ID: 1}, nil, command.DefaultTokenComparer); err != nil {
+		t.Error("there should be no error:", err)
+	}
+}
+
+type nestedModel struct {
+	ID    int
+	Tags  []string
+	Attrs map[string]*mocks.SimpleModel
+	Child *mocks.SimpleModel
+}
+
+func (m *nestedModel) EntityID() command.EntityID { return command.IntEntityID(m.ID) }
+
+func TestRepositoryDeepCopies(t *testing.T) {
+	r := NewRepository()
+
+	model := &nestedModel{
+		ID:    1,
+		Tags:  []string{"a"},
+		Attrs: map[string]*mocks.SimpleModel{"x": {ID: 2, Content: "x"}},
+		Child: &mocks.SimpleModel{ID: 3, Content: "child"},
+	}
+	if err := r.Save(model); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	model.Tags[0] = "changed"
+	model.Attrs["x"].Content = "changed"
+	model.Child.Content = "changed"
+
+	found := &nestedModel{ID: 1}
+	if err := r.Find(found); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if found.Tags[0] != "a" || found.Attrs["x"].Content != "x" || found.Child.Content != "child" {
+		t.Error("the stored entity should be deep copied on write:", found)
+	}
+
+	found.Child.Content = "changed after find"
+	again := &nestedModel{ID: 1}
+	r.Find(again)
+	if again.Child.Content != "child" {
+		t.Error("the stored entity should be deep copied on read:", again.Child)
+	}
+}
+
+func TestRepositoryKeysByType(t *testing.T) {
+	r := NewRepository()
+	r.Save(&mocks.SimpleModel{ID: 1, Content: "simple"})
+	r.Save(&mocks.MockVersionableModel{ID: 1, Content: "versionable"})
+
+	simple := &mocks.SimpleModel{ID: 1}
+	r.Find(simple)
+	versionable := &mocks.MockVersionableModel{ID: 1}
+	r.Find(versionable)
+	if simple.Content != "simple" || versionable.Content != "versionable" {
+		t.Error("entities with the same id and different types should not collide:", simple, versionable)
+	}
+}
+
+func TestRepositoryListAndQuery(t *testing.T) {
+	r := NewRepository()
+	for i, content := range []string{"c", "a", "b"} {
+		r.Save(&mocks.SimpleModel{ID: i + 1, Content: content})
+	}
+	r.Save(&mocks.MockVersionableModel{ID: 9})
+
+	all, err := r.List(&mocks.SimpleModel{})
+	if err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if len(all) != 3 || all[0].EntityID() != "1" || all[2].EntityID() != "3" {
+		t.Error("all simple models should be listed by id:", all)
+	}
+
+	matched, _ := r.Query(&mocks.SimpleModel{}, func(e command.Entity) bool {
+		return e.(*mocks.SimpleModel).Content != "c"
+	})
+	if len(matched) != 2 || matched[0].EntityID() != "2" {
+		t.Error("only matching simple models should be returned:", matched)
+	}
+
+	if count, _ := r.Count(&mocks.MockVersionableModel{}); count != 1 {
+		t.Error("there should be one versionable model:", count)
+	}
+}
+
+func TestRepositoryConcurrentConditionalSaves(t *testing.T) {
+	r := NewRepository()
+	r.Save(&mocks.MockVersionableModel{ID: 1, VersionInt: 1})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	saved := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if r.SaveIfVersion(&mocks.MockVersionableModel{ID: 1, VersionInt: 2}, 1) == nil {
+				mu.Lock()
+				saved++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if saved != 1 {
+		t.Error("exactly one conditional save should succeed:", saved)
+	}
+}
+
+func TestRepositoryPartitionsByTenant(t *testing.T) {
+	r := NewRepository()
+	acme := r.ForTenant("acme")
+	globex := r.ForTenant("globex")
+
+	if err := acme.Save(&mocks.SimpleModel{ID: 1, Content: "acme"}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if err := globex.Find(&mocks.SimpleModel{ID: 1}); err != command.ErrEntityNotFound {
+		t.Error("another tenant should not see the entity:", err)
+	}
+	if err := r.Find(&mocks.SimpleModel{ID: 1}); err != command.ErrEntityNotFound {
+		t.Error("the repository without tenant should not see the entity:", err)
+	}
+
+	found := &mocks.SimpleModel{ID: 1}
+	if err := r.ForTenant("acme").Find(found); err != nil || found.Content != "acme" {
+		t.Error("the tenant should see its entity:", found, err)
+	}
+
+	if count, _ := globex.(*Repository).Count(&mocks.SimpleModel{}); count != 0 {
+		t.Error("another tenant should not count the entity:", count)
+	}
+}
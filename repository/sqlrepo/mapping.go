@@ -0,0 +1,171 @@
+package sqlrepo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/gapsquare/command"
+)
+
+// tagName is the struct tag holding the column mapping, e.g. `db:"id,key"` or `db:"version,version"`
+const tagName = "db"
+
+var errNotStructPointer = errors.New("entity must be a pointer to a struct")
+
+// Field maps an entity field to a table column
+type Field struct {
+	// Column name of the field
+	Column string
+	// Value is a pointer to the entity field
+	Value interface{}
+	// Key is true for the primary key column
+	Key bool
+	// Version is true for the version column used for optimistic locking
+	Version bool
+}
+
+// TableNamer is an entity which provides its table name,
+// tag mapped entities without it use the snake cased type name
+type TableNamer interface {
+	TableName() string
+}
+
+// TableMapper is an entity which describes its table mapping itself instead of using struct tags
+type TableMapper interface {
+	TableNamer
+
+	// Fields returns the mapped fields, exactly one has to be the key
+	Fields() []Field
+}
+
+// mapping is the resolved table mapping of an entity
+type mapping struct {
+	table   string
+	key     Field
+	version *Field
+	columns []Field
+}
+
+// all returns every mapped field, key first
+func (m mapping) all() []Field {
+	fields := append([]Field{m.key}, m.columns...)
+	if m.version != nil {
+		fields = append(fields, *m.version)
+	}
+	return fields
+}
+
+func mappingOf(entity command.Entity) (mapping, error) {
+	var fields []Field
+	var table string
+
+	if m, ok := entity.(TableMapper); ok {
+		table = m.TableName()
+		fields = m.Fields()
+	} else {
+		var err error
+		if table, fields, err = tagMapping(entity); err != nil {
+			return mapping{}, err
+		}
+	}
+
+	m := mapping{table: table}
+	keys := 0
+	for i := range fields {
+		switch {
+		case fields[i].Key:
+			keys++
+			m.key = fields[i]
+		case fields[i].Version:
+			if !isInteger(fields[i].Value) {
+				return mapping{}, fmt.Errorf("version column %s of %s must be an integer", fields[i].Column, table)
+			}
+			m.version = &fields[i]
+		default:
+			m.columns = append(m.columns, fields[i])
+		}
+	}
+
+	if keys != 1 {
+		return mapping{}, fmt.Errorf("table %s must have exactly one key column, found %d", table, keys)
+	}
+
+	return m, nil
+}
+
+func tagMapping(entity command.Entity) (string, []Field, error) {
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return "", nil, errNotStructPointer
+	}
+	v = v.Elem()
+
+	table := snakeCase(v.Type().Name())
+	if n, ok := entity.(TableNamer); ok {
+		table = n.TableName()
+	}
+
+	var fields []Field
+	for i := 0; i < v.NumField(); i++ {
+		tag, ok := v.Type().Field(i).Tag.Lookup(tagName)
+		if !ok || tag == "-" || !v.Field(i).CanSet() {
+			continue
+		}
+
+		options := strings.Split(tag, ",")
+		f := Field{Column: options[0], Value: v.Field(i).Addr().Interface()}
+		if f.Column == "" {
+			f.Column = snakeCase(v.Type().Field(i).Name)
+		}
+		for _, o := range options[1:] {
+			switch o {
+			case "key":
+				f.Key = true
+			case "version":
+				f.Version = true
+			default:
+				return "", nil, fmt.Errorf("unknown %s tag option %q on field %s", tagName, o, v.Type().Field(i).Name)
+			}
+		}
+		fields = append(fields, f)
+	}
+
+	return table, fields, nil
+}
+
+func isInteger(ptr interface{}) bool {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr {
+		return false
+	}
+
+	switch v.Elem().Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func snakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// valueOf returns the value of a mapped field
+func valueOf(f Field) interface{} {
+	return reflect.ValueOf(f.Value).Elem().Interface()
+}
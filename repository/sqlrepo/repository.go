@@ -0,0 +1,332 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gapsquare/command"
+)
+
+// Placeholder formats the n-th (1 based) query parameter placeholder
+type Placeholder func(n int) string
+
+// QuestionPlaceholder formats placeholders as ?, used by SQLite and MySQL
+func QuestionPlaceholder(int) string { return "?" }
+
+// DollarPlaceholder formats placeholders as $n, used by PostgreSQL
+func DollarPlaceholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+// Dialect is the SQL dialect of the database
+type Dialect struct {
+	// Placeholder formats the query parameters
+	Placeholder Placeholder
+	// insertIgnore formats an insert which does nothing if a row with the same key exists
+	insertIgnore func(table, columns, values, key string) string
+}
+
+// Supported dialects
+var (
+	// SQLite dialect, it requires SQLite 3.24 or later
+	SQLite = Dialect{Placeholder: QuestionPlaceholder, insertIgnore: onConflictDoNothing}
+	// PostgreSQL dialect, it requires PostgreSQL 9.5 or later
+	PostgreSQL = Dialect{Placeholder: DollarPlaceholder, insertIgnore: onConflictDoNothing}
+	// MySQL dialect, the connection must not use the clientFoundRows parameter
+	MySQL = Dialect{Placeholder: QuestionPlaceholder, insertIgnore: onDuplicateKeyNoop}
+)
+
+func onConflictDoNothing(table, columns, values, key string) string {
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO NOTHING", table, columns, values, key)
+}
+
+func onDuplicateKeyNoop(table, columns, values, key string) string {
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s = %s", table, columns, values, key, key)
+}
+
+// Option configures a Repository
+type Option func(*Repository)
+
+// WithDialect sets the SQL dialect of the database, default SQLite
+func WithDialect(d Dialect) Option {
+	return func(r *Repository) {
+		r.dialect = d
+	}
+}
+
+// WithPlaceholder sets the query placeholder format of the dialect
+func WithPlaceholder(p Placeholder) Option {
+	return func(r *Repository) {
+		r.dialect.Placeholder = p
+	}
+}
+
+// conn is implemented by both *sql.DB and *sql.Tx
+type conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Repository is a command.ReadWriteRepository over database/sql.
+// Entities describe their table mapping with `db` struct tags or by implementing TableMapper.
+// Entities with a version column are saved with optimistic locking through SaveIfVersion.
+// The queries of a transaction use the context of BeginTransaction, so they stop with the execution,
+// the queries of the Repository itself are not bound to a context
+type Repository struct {
+	queries
+	db *sql.DB
+}
+
+var _ = command.TransactionalRepository(&Repository{})
+var _ = command.ConditionalWriteRepository(&Repository{})
+
+// NewRepository creates a Repository on db
+func NewRepository(db *sql.DB, options ...Option) *Repository {
+	r := &Repository{db: db}
+	r.dialect = SQLite
+	for _, option := range options {
+		option(r)
+	}
+	r.conn = db
+	r.ctx = context.Background()
+	return r
+}
+
+// BeginTransaction implements the BeginTransaction method of command.TransactionalRepository
+func (r *Repository) BeginTransaction(ctx context.Context) (command.Transaction, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{queries: queries{conn: tx, dialect: r.dialect, ctx: ctx}, tx: tx}, nil
+}
+
+// Tx is a Repository bound to a database transaction
+type Tx struct {
+	queries
+	tx *sql.Tx
+}
+
+var _ = command.Transaction(&Tx{})
+var _ = command.ConditionalWriteRepository(&Tx{})
+
+// SQLTx returns the underlying transaction, so a command.Store can write in the same transaction
+func (t *Tx) SQLTx() *sql.Tx {
+	return t.tx
+}
+
+// Commit implements the Commit method of command.Transaction
+func (t *Tx) Commit() error {
+	return t.tx.Commit()
+}
+
+// Rollback implements the Rollback method of command.Transaction
+func (t *Tx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// TxFromContext returns the SQL transaction of the running command execution, if it uses a sqlrepo Repository
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	if tx, ok := command.TransactionFromContext(ctx); ok {
+		if t, ok := tx.(*Tx); ok {
+			return t.tx, true
+		}
+	}
+	return nil, false
+}
+
+// queries implements the repository methods on a connection or a transaction
+type queries struct {
+	conn    conn
+	dialect Dialect
+	ctx     context.Context
+}
+
+func (q queries) placeholder(n int) string {
+	return q.dialect.Placeholder(n)
+}
+
+// Find implements the Find method of command.ReadRepository
+func (q queries) Find(entity command.Entity) error {
+	m, err := mappingOf(entity)
+	if err != nil {
+		return err
+	}
+
+	fields := m.all()
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s",
+		columnList(fields), m.table, m.key.Column, q.placeholder(1))
+
+	dest := make([]interface{}, len(fields))
+	for i, f := range fields {
+		dest[i] = f.Value
+	}
+
+	err = q.conn.QueryRowContext(q.ctx, query, keyArg(m)).Scan(dest...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return command.ErrEntityNotFound
+	}
+	return err
+}
+
+// Save implements the Save method of command.WriteRepository, it updates or inserts the entity without version check
+func (q queries) Save(entity command.Entity) error {
+	m, err := mappingOf(entity)
+	if err != nil {
+		return err
+	}
+
+	updated, err := q.update(m, nil)
+	if err != nil || updated {
+		return err
+	}
+
+	return q.insert(m)
+}
+
+// SaveIfVersion implements the SaveIfVersion method of command.ConditionalWriteRepository,
+// entities without a version column are saved without version check
+func (q queries) SaveIfVersion(entity command.Entity, expected command.VersionType) error {
+	m, err := mappingOf(entity)
+	if err != nil {
+		return err
+	}
+
+	if m.version == nil {
+		return q.Save(entity)
+	}
+
+	if expected == 0 {
+		return q.insertIfNotExists(m)
+	}
+
+	updated, err := q.update(m, &expected)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return command.ErrConcurrencyConflict
+	}
+	return nil
+}
+
+// Remove implements the Remove method of command.WriteRepository
+func (q queries) Remove(entity command.Entity) error {
+	m, err := mappingOf(entity)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = %s", m.table, m.key.Column, q.placeholder(1))
+	res, err := q.conn.ExecContext(q.ctx, query, keyArg(m))
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return command.ErrEntityNotFound
+	}
+	return nil
+}
+
+// update updates the entity row, if expected is not nil only when its version column equals expected.
+// It returns false if no row was updated
+func (q queries) update(m mapping, expected *command.VersionType) (bool, error) {
+	fields := m.columns
+	if m.version != nil {
+		fields = append(fields[:len(fields):len(fields)], *m.version)
+	}
+	if len(fields) == 0 {
+		return q.exists(m)
+	}
+
+	set := make([]string, len(fields))
+	args := make([]interface{}, 0, len(fields)+2)
+	for i, f := range fields {
+		set[i] = fmt.Sprintf("%s = %s", f.Column, q.placeholder(i+1))
+		args = append(args, valueOf(f))
+	}
+
+	args = append(args, keyArg(m))
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s = %s",
+		m.table, strings.Join(set, ", "), m.key.Column, q.placeholder(len(args)))
+
+	if expected != nil {
+		args = append(args, int64(*expected))
+		query += fmt.Sprintf(" AND %s = %s", m.version.Column, q.placeholder(len(args)))
+	}
+
+	res, err := q.conn.ExecContext(q.ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (q queries) insert(m mapping) error {
+	fields := m.all()
+	placeholders := make([]string, len(fields))
+	args := make([]interface{}, len(fields))
+	for i, f := range fields {
+		placeholders[i] = q.placeholder(i + 1)
+		args[i] = valueOf(f)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		m.table, columnList(fields), strings.Join(placeholders, ", "))
+	_, err := q.conn.ExecContext(q.ctx, query, args...)
+	return err
+}
+
+// insertIfNotExists inserts the entity row only if its key is not stored yet,
+// it returns command.ErrConcurrencyConflict if another writer created the row first
+func (q queries) insertIfNotExists(m mapping) error {
+	fields := m.all()
+	placeholders := make([]string, len(fields))
+	args := make([]interface{}, len(fields))
+	for i, f := range fields {
+		placeholders[i] = q.placeholder(i + 1)
+		args[i] = valueOf(f)
+	}
+
+	query := q.dialect.insertIgnore(m.table, columnList(fields), strings.Join(placeholders, ", "), m.key.Column)
+	res, err := q.conn.ExecContext(q.ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return command.ErrConcurrencyConflict
+	}
+	return nil
+}
+
+func (q queries) exists(m mapping) (bool, error) {
+	var one int
+	query := fmt.Sprintf("SELECT 1 FROM %s WHERE %s = %s", m.table, m.key.Column, q.placeholder(1))
+	err := q.conn.QueryRowContext(q.ctx, query, keyArg(m)).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func keyArg(m mapping) interface{} {
+	return valueOf(m.key)
+}
+
+func columnList(fields []Field) string {
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.Column
+	}
+	return strings.Join(columns, ", ")
+}
@@ -0,0 +1,246 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/command/mocks"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type order struct {
+	ID     string `db:"id,key"`
+	Status string `db:"status"`
+	Ver    uint64 `db:"version,version"`
+	Note   string
+}
+
+func (o *order) EntityID() command.EntityID   { return command.EntityID(o.ID) }
+func (o *order) Version() command.VersionType { return command.VersionType(o.Ver) }
+func (o *order) IncrementVersion()            { o.Ver++ }
+func (o *order) TableName() string            { return "orders" }
+
+type customer struct {
+	Key  int
+	Name string
+}
+
+func (c *customer) EntityID() command.EntityID { return command.IntEntityID(c.Key) }
+func (c *customer) TableName() string          { return "customers" }
+func (c *customer) Fields() []Field {
+	return []Field{
+		{Column: "customer_id", Value: &c.Key, Key: true},
+		{Column: "full_name", Value: &c.Name},
+	}
+}
+
+const shipOrderType = command.Type("sqlrepo.order.ship")
+
+type shipOrder struct {
+	ID  string
+	Ver command.VersionType
+}
+
+func (c *shipOrder) CommandType() command.Type    { return shipOrderType }
+func (c *shipOrder) Entity() command.Entity       { return &order{ID: c.ID} }
+func (c *shipOrder) Version() command.VersionType { return c.Ver }
+
+// txStore saves commands in the executer's transaction
+type txStore struct{}
+
+func (txStore) Save(cmd command.Command, r command.WriteRepository) error {
+	_, err := r.(*Tx).SQLTx().Exec("INSERT INTO commands (type) VALUES (?)", string(cmd.CommandType()))
+	return err
+}
+
+const cancelOrderType = command.Type("sqlrepo.order.cancel")
+
+type cancelOrder struct {
+	ID string
+}
+
+func (c *cancelOrder) CommandType() command.Type { return cancelOrderType }
+func (c *cancelOrder) Entity() command.Entity    { return &order{ID: c.ID} }
+
+var cancelErr error
+
+func init() {
+	command.RegisterCommandHandler(shipOrderType, &mocks.MockCommandHandler{})
+	command.RegisterCommandHandler(cancelOrderType, command.NewDestructiveHandler(&mocks.MockCommandHandler{},
+		func(ctx context.Context, cmd command.Command) error {
+			if cancelErr != nil {
+				return cancelErr
+			}
+			tx, _ := command.TransactionFromContext(ctx)
+			return tx.Remove(cmd.Entity())
+		}))
+}
+
+func openDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+
+	for _, stmt := range []string{
+		"CREATE TABLE orders (id TEXT PRIMARY KEY, status TEXT, version INTEGER)",
+		"CREATE TABLE customers (customer_id INTEGER PRIMARY KEY, full_name TEXT)",
+		"CREATE TABLE commands (type TEXT)",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestRepositoryTagMapping(t *testing.T) {
+	r := NewRepository(openDB(t))
+
+	if err := r.Find(&order{ID: "o1"}); err != command.ErrEntityNotFound {
+		t.Error("there should be a not found error:", err)
+	}
+
+	if err := r.Save(&order{ID: "o1", Status: "new", Ver: 1}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if err := r.Save(&order{ID: "o1", Status: "paid", Ver: 2}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	found := &order{ID: "o1"}
+	if err := r.Find(found); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if found.Status != "paid" || found.Ver != 2 {
+		t.Error("the order should be updated:", found)
+	}
+
+	if err := r.Remove(found); err != nil {
+		t.Error("there should be no error:", err)
+	}
+	if err := r.Remove(found); err != command.ErrEntityNotFound {
+		t.Error("there should be a not found error:", err)
+	}
+}
+
+func TestRepositoryTableMapper(t *testing.T) {
+	r := NewRepository(openDB(t))
+
+	if err := r.Save(&customer{Key: 7, Name: "Ada"}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	found := &customer{Key: 7}
+	if err := r.Find(found); err != nil || found.Name != "Ada" {
+		t.Error("the customer should be found:", found, err)
+	}
+}
+
+func TestRepositorySaveIfVersion(t *testing.T) {
+	r := NewRepository(openDB(t))
+
+	if err := r.SaveIfVersion(&order{ID: "o1", Status: "new", Ver: 1}, 0); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if err := r.SaveIfVersion(&order{ID: "o1", Status: "paid", Ver: 2}, 1); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if err := r.SaveIfVersion(&order{ID: "o1", Status: "stale", Ver: 2}, 1); err != command.ErrConcurrencyConflict {
+		t.Error("there should be a concurrency conflict:", err)
+	}
+}
+
+func TestRepositorySaveIfVersionConcurrentCreate(t *testing.T) {
+	r := NewRepository(openDB(t))
+
+	if err := r.SaveIfVersion(&order{ID: "o1", Status: "first", Ver: 1}, 0); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if err := r.SaveIfVersion(&order{ID: "o1", Status: "second", Ver: 1}, 0); err != command.ErrConcurrencyConflict {
+		t.Error("the second create should be a concurrency conflict:", err)
+	}
+
+	found := &order{ID: "o1"}
+	if err := r.Find(found); err != nil || found.Status != "first" {
+		t.Error("the first create should be kept:", found, err)
+	}
+}
+
+func TestTransactionUsesContext(t *testing.T) {
+	r := NewRepository(openDB(t))
+	ctx, cancel := context.WithCancel(context.Background())
+	tx, err := r.BeginTransaction(ctx)
+	if err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	cancel()
+	if err := tx.Save(&order{ID: "o1", Status: "new"}); err == nil {
+		t.Error("the queries of a cancelled execution should fail")
+	}
+}
+
+func TestDialectInsertIgnore(t *testing.T) {
+	for _, c := range []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{PostgreSQL, "INSERT INTO orders (id, status) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING"},
+		{MySQL, "INSERT INTO orders (id, status) VALUES (?, ?) ON DUPLICATE KEY UPDATE id = id"},
+	} {
+		values := c.dialect.Placeholder(1) + ", " + c.dialect.Placeholder(2)
+		if query := c.dialect.insertIgnore("orders", "id, status", values, "id"); query != c.expected {
+			t.Error("unexpected insert:", query)
+		}
+	}
+}
+
+func TestExecuterJoinsTransaction(t *testing.T) {
+	db := openDB(t)
+	r := NewRepository(db)
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(txStore{}),
+		command.WithEventBus(&mocks.EventBus{}),
+	), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	countCommands := func() int {
+		var n int
+		db.QueryRow("SELECT COUNT(*) FROM commands").Scan(&n)
+		return n
+	}
+
+	if err := ce.Execute(context.Background(), &shipOrder{ID: "o1"}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if err := ce.Execute(context.Background(), &shipOrder{ID: "o1"}); err != command.ErrVersionMismatched {
+		t.Error("there should be a version mismatch:", err)
+	}
+
+	cancelErr = errors.New("can not cancel")
+	if err := ce.Execute(context.Background(), &cancelOrder{ID: "o1"}); err != cancelErr {
+		t.Error("there should be a cancel error:", err)
+	}
+	if n := countCommands(); n != 1 {
+		t.Error("the failed command should be rolled back:", n)
+	}
+
+	cancelErr = nil
+	if err := ce.Execute(context.Background(), &cancelOrder{ID: "o1"}); err != nil {
+		t.Error("there should be no error:", err)
+	}
+	if n := countCommands(); n != 2 {
+		t.Error("the cancel command should be committed:", n)
+	}
+	if err := r.Find(&order{ID: "o1"}); err != command.ErrEntityNotFound {
+		t.Error("the order should be removed in the transaction:", err)
+	}
+}
@@ -0,0 +1,243 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/goevent"
+)
+
+// Type is the type of a saga
+type Type string
+
+// Step is a command dispatched by a saga, with the command compensating it if a later command fails
+type Step struct {
+	Command command.Command
+	// Compensation is optional, its factory must be registered with command.RegisterCommand to be persisted
+	Compensation command.Command
+}
+
+// Saga turns the events of a correlation ID into commands
+type Saga interface {
+	// SagaType returns the type of the saga
+	SagaType() Type
+	// Matcher returns the matcher of the events handled by the saga
+	Matcher() goevent.EventMatcher
+	// RunSaga handles an event of a running instance, it can modify the state and returns the steps to dispatch
+	RunSaga(ctx context.Context, ev goevent.Event, state *State) ([]Step, error)
+}
+
+// CorrelationFunc returns the correlation ID of the saga instance handling an event, false if it has none
+type CorrelationFunc func(goevent.Event) (string, bool)
+
+// Option configures a Manager
+type Option func(*Manager)
+
+// WithCorrelation sets the CorrelationFunc, e.g. to read the correlation ID from the event data.
+// The default command.EventCorrelationID reads the metadata stamped on the events by the executer, which
+// is lost if the events are published to another process without a command.EventEnvelope
+func WithCorrelation(fn CorrelationFunc) Option {
+	return func(m *Manager) {
+		m.correlation = fn
+	}
+}
+
+// WithClock sets the function returning the current time, useful in tests
+func WithClock(now func() time.Time) Option {
+	return func(m *Manager) {
+		m.now = now
+	}
+}
+
+// Manager runs the sagas on the events of an event bus and dispatches their commands with an Executer
+type Manager struct {
+	bus         goevent.EventBus
+	executer    command.Executer
+	store       StateStore
+	correlation CorrelationFunc
+	now         func() time.Time
+
+	mu    sync.Mutex
+	locks map[stateKey]*instanceLock
+}
+
+type instanceLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewManager creates a Manager
+func NewManager(bus goevent.EventBus, executer command.Executer, store StateStore, options ...Option) *Manager {
+	m := &Manager{
+		bus:         bus,
+		executer:    executer,
+		store:       store,
+		correlation: command.EventCorrelationID,
+		now:         time.Now,
+		locks:       make(map[stateKey]*instanceLock),
+	}
+	for _, option := range options {
+		option(m)
+	}
+	return m
+}
+
+// Register subscribes the saga to its events on the bus. Events without correlation ID are ignored,
+// see command.WithCorrelationID and WithCorrelation
+func (m *Manager) Register(s Saga) error {
+	matcher := s.Matcher()
+	return m.bus.AddHandler(func(ev goevent.Event) bool {
+		_, ok := m.correlation(ev)
+		return ok && matcher(ev)
+	}, &handler{manager: m, saga: s})
+}
+
+type handler struct {
+	manager *Manager
+	saga    Saga
+}
+
+func (h *handler) HandlerType() goevent.EventHandlerType {
+	return goevent.EventHandlerType("saga." + string(h.saga.SagaType()))
+}
+
+func (h *handler) HandleEvent(ctx context.Context, ev goevent.Event) error {
+	return h.manager.Handle(ctx, h.saga, ev)
+}
+
+// Handle runs the saga on an event, the events of an instance are handled one at a time. The commands are
+// dispatched with the metadata, including the correlation ID, and the tenant of the event, on a context which
+// is not cancelled with the one of the publisher. When a command fails, the compensating
+// commands of the dispatched commands are executed in reverse order and the error is returned
+func (m *Manager) Handle(ctx context.Context, s Saga, ev goevent.Event) error {
+	id, ok := m.correlation(ev)
+	if !ok {
+		return nil
+	}
+
+	// the saga outlives the execution publishing the event, whose context is cancelled once it returns
+	ctx = context.WithoutCancel(ctx)
+	for k, v := range command.EventMetadata(ev) {
+		if k == command.MetadataTenantID {
+			ctx = command.WithTenant(ctx, command.TenantID(v))
+			continue
+		}
+		ctx = command.WithMetadata(ctx, k, v)
+	}
+
+	key := stateKey{s.SagaType(), id}
+	m.lock(key)
+	defer m.unlock(key)
+
+	state, err := m.store.Load(ctx, s.SagaType(), id)
+	if errors.Is(err, ErrNotFound) {
+		state, err = State{SagaType: s.SagaType(), ID: id, Status: StatusRunning}, nil
+	}
+	if err != nil {
+		return err
+	}
+	if state.Status != StatusRunning {
+		return nil
+	}
+
+	steps, err := s.RunSaga(ctx, ev, &state)
+	if err == nil {
+		err = m.dispatch(ctx, steps, &state)
+	}
+	if err != nil {
+		state.Error = err.Error()
+		if e := m.compensate(ctx, &state); e != nil {
+			err = fmt.Errorf("%w, compensation failed: %v", err, e)
+		}
+	}
+
+	if e := m.save(ctx, &state); e != nil {
+		return e
+	}
+	return err
+}
+
+// save persists the state
+func (m *Manager) save(ctx context.Context, state *State) error {
+	state.UpdatedAt = m.now()
+	return m.store.Save(ctx, *state)
+}
+
+// dispatch executes the steps, the compensations of the successful steps are added to the state
+// and persisted after each step
+func (m *Manager) dispatch(ctx context.Context, steps []Step, state *State) error {
+	for _, step := range steps {
+		var compensation command.Envelope
+		if step.Compensation != nil {
+			envelope, err := command.NewEnvelope(ctx, step.Compensation)
+			if err != nil {
+				return err
+			}
+			compensation = envelope
+		}
+
+		if err := m.executer.Execute(ctx, step.Command); err != nil {
+			return err
+		}
+
+		if step.Compensation != nil {
+			state.Compensations = append(state.Compensations, compensation)
+		}
+		if err := m.save(ctx, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compensate executes the compensations in reverse order and persists the state after each one,
+// the failed compensation and the ones before it are kept in the state
+func (m *Manager) compensate(ctx context.Context, state *State) error {
+	for len(state.Compensations) > 0 {
+		envelope := state.Compensations[len(state.Compensations)-1]
+
+		cmd, err := envelope.Command()
+		if err == nil {
+			err = m.executer.Execute(envelope.Context(ctx), cmd)
+		}
+		if err == nil {
+			state.Compensations = state.Compensations[:len(state.Compensations)-1]
+			err = m.save(ctx, state)
+		}
+		if err != nil {
+			state.Status = StatusFailed
+			return err
+		}
+	}
+
+	state.Status = StatusCompensated
+	return nil
+}
+
+func (m *Manager) lock(key stateKey) {
+	m.mu.Lock()
+	l, ok := m.locks[key]
+	if !ok {
+		l = &instanceLock{}
+		m.locks[key] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+
+	l.Lock()
+}
+
+func (m *Manager) unlock(key stateKey) {
+	m.mu.Lock()
+	l := m.locks[key]
+	if l.refs--; l.refs == 0 {
+		delete(m.locks, key)
+	}
+	m.mu.Unlock()
+
+	l.Unlock()
+}
@@ -0,0 +1,296 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/goevent"
+
+	"github.com/gapsquare/command/mocks"
+)
+
+const (
+	reserveType = command.Type("saga.reserve")
+	releaseType = command.Type("saga.release")
+	chargeType  = command.Type("saga.charge")
+
+	orderPlaced    = goevent.EventTopic("order.placed")
+	stockReserved  = goevent.EventTopic("stock.reserved")
+	paymentCharged = goevent.EventTopic("payment.charged")
+)
+
+type orderCommand struct {
+	Type    command.Type `json:"type"`
+	OrderID int          `json:"order_id"`
+}
+
+func (c *orderCommand) CommandType() command.Type { return c.Type }
+func (c *orderCommand) Entity() command.Entity    { return &mocks.SimpleModel{ID: c.OrderID} }
+
+func init() {
+	command.RegisterCommand(releaseType, func() command.Command { return &orderCommand{Type: releaseType} })
+}
+
+type orderSaga struct{}
+
+func (orderSaga) SagaType() Type { return "order" }
+
+func (orderSaga) Matcher() goevent.EventMatcher {
+	return goevent.MatchAnyOfTopic(orderPlaced, stockReserved, paymentCharged)
+}
+
+func (orderSaga) RunSaga(_ context.Context, ev goevent.Event, state *State) ([]Step, error) {
+	switch ev.Topic() {
+	case orderPlaced:
+		state.Set("order", "1")
+		return []Step{{
+			Command:      &orderCommand{Type: reserveType, OrderID: 1},
+			Compensation: &orderCommand{Type: releaseType, OrderID: 1},
+		}}, nil
+	case stockReserved:
+		return []Step{{Command: &orderCommand{Type: chargeType, OrderID: 1}}}, nil
+	}
+	state.Complete()
+	return nil, nil
+}
+
+// bus delivers the published events when drained, the handlers of an event can publish new events
+type bus struct {
+	matchers []goevent.EventMatcher
+	handlers []goevent.EventHandler
+	queue    []goevent.Event
+	errs     []error
+}
+
+func (b *bus) Publish(_ context.Context, ev goevent.Event) error {
+	b.queue = append(b.queue, ev)
+	return nil
+}
+
+func (b *bus) AddHandler(m goevent.EventMatcher, h goevent.EventHandler) error {
+	b.matchers = append(b.matchers, m)
+	b.handlers = append(b.handlers, h)
+	return nil
+}
+
+func (b *bus) Errors() <-chan goevent.EventBusError { return nil }
+
+func (b *bus) drain(ctx context.Context) {
+	for len(b.queue) > 0 {
+		ev := b.queue[0]
+		b.queue = b.queue[1:]
+		for i, m := range b.matchers {
+			if m(ev) {
+				if err := b.handlers[i].HandleEvent(ctx, ev); err != nil {
+					b.errs = append(b.errs, err)
+				}
+			}
+		}
+	}
+}
+
+// executer publishes the event of a command with the correlation ID of the context
+type executer struct {
+	bus      *bus
+	err      map[command.Type]error
+	executed []command.Type
+	tenants  []command.TenantID
+	before   func(command.Command)
+}
+
+func (e *executer) Execute(ctx context.Context, cmd command.Command) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if e.before != nil {
+		e.before(cmd)
+	}
+	e.executed = append(e.executed, cmd.CommandType())
+	tenant, _ := command.TenantFromContext(ctx)
+	e.tenants = append(e.tenants, tenant)
+	if err := e.err[cmd.CommandType()]; err != nil {
+		return err
+	}
+
+	topic := map[command.Type]goevent.EventTopic{reserveType: stockReserved, chargeType: paymentCharged}[cmd.CommandType()]
+	if topic != "" {
+		id, _ := command.CorrelationID(ctx)
+		ev := command.EventWithMetadata(goevent.NewEvent(topic, nil), command.MetadataCorrelationID, id)
+		e.bus.Publish(ctx, command.EventWithMetadata(ev, command.MetadataTenantID, string(tenant)))
+	}
+	return nil
+}
+
+func place(b *bus, id string) {
+	ev := goevent.NewEvent(orderPlaced, nil)
+	ev = command.EventWithMetadata(ev, command.MetadataCorrelationID, id)
+	ev = command.EventWithMetadata(ev, command.MetadataTenantID, "acme")
+	b.Publish(context.Background(), ev)
+}
+
+func TestManager_Completes(t *testing.T) {
+	b := &bus{}
+	e := &executer{bus: b}
+	store := NewMemoryStateStore()
+	m := NewManager(b, e, store)
+	if err := m.Register(orderSaga{}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	// the events are delivered with the context of the publisher, cancelled once its execution returns
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	place(b, "c-1")
+	b.Publish(context.Background(), goevent.NewEvent(orderPlaced, nil))
+	b.drain(cancelled)
+
+	if len(b.errs) != 0 {
+		t.Error("there should be no error:", b.errs)
+	}
+	if len(e.executed) != 2 || e.executed[0] != reserveType || e.executed[1] != chargeType || e.tenants[1] != "acme" {
+		t.Error("the commands should be dispatched with the tenant of the events:", e.executed, e.tenants)
+	}
+
+	state, err := store.Load(context.Background(), "order", "c-1")
+	if err != nil || state.Status != StatusCompleted || state.Get("order") != "1" || len(state.Compensations) != 1 {
+		t.Error("the state should be completed:", state, err)
+	}
+
+	place(b, "c-1")
+	b.drain(context.Background())
+	if len(e.executed) != 2 {
+		t.Error("a completed saga should ignore its events:", e.executed)
+	}
+}
+
+func TestManager_Compensates(t *testing.T) {
+	errDeclined := errors.New("payment declined")
+	b := &bus{}
+	e := &executer{bus: b, err: map[command.Type]error{chargeType: errDeclined, releaseType: errors.New("can not release")}}
+	store := NewMemoryStateStore()
+	NewManager(b, e, store).Register(orderSaga{})
+
+	place(b, "c-1")
+	b.drain(context.Background())
+
+	if len(b.errs) != 1 || !errors.Is(b.errs[0], errDeclined) {
+		t.Error("the failure should be returned to the bus:", b.errs)
+	}
+	state, _ := store.Load(context.Background(), "order", "c-1")
+	if state.Status != StatusFailed || len(state.Compensations) != 1 || state.Error == "" {
+		t.Error("the failed compensation should be kept:", state)
+	}
+
+	// the manager restarts after the stock of c-2 is reserved, the new manager compensates with the persisted state
+	delete(e.err, releaseType)
+	e.executed = nil
+	b, restarted := &bus{}, &bus{}
+	NewManager(b, e, store).Register(orderSaga{})
+	NewManager(restarted, e, store).Register(orderSaga{})
+
+	e.bus = restarted
+	place(b, "c-2")
+	b.drain(context.Background())
+	restarted.drain(context.Background())
+
+	state, _ = store.Load(context.Background(), "order", "c-2")
+	if state.Status != StatusCompensated || len(state.Compensations) != 0 {
+		t.Error("the saga should be compensated:", state)
+	}
+	if len(e.executed) != 3 || e.executed[2] != releaseType || e.tenants[len(e.tenants)-1] != "acme" {
+		t.Error("the compensating command should be executed with the tenant:", e.executed, e.tenants)
+	}
+}
+
+type shipSaga struct{}
+
+func (shipSaga) SagaType() Type                { return "ship" }
+func (shipSaga) Matcher() goevent.EventMatcher { return goevent.MatchTopic(orderPlaced) }
+
+func (shipSaga) RunSaga(_ context.Context, _ goevent.Event, _ *State) ([]Step, error) {
+	return []Step{
+		{Command: &orderCommand{Type: reserveType, OrderID: 1}, Compensation: &orderCommand{Type: releaseType, OrderID: 1}},
+		{Command: &orderCommand{Type: chargeType, OrderID: 1}},
+	}, nil
+}
+
+// failingStore fails the given save
+type failingStore struct {
+	*MemoryStateStore
+	saves, fail int
+}
+
+var errSave = errors.New("can not save")
+
+func (s *failingStore) Save(ctx context.Context, state State) error {
+	if s.saves++; s.saves == s.fail {
+		return errSave
+	}
+	return s.MemoryStateStore.Save(ctx, state)
+}
+
+func TestManager_PersistsEachStep(t *testing.T) {
+	b := &bus{}
+	e := &executer{bus: &bus{}}
+	store := &failingStore{MemoryStateStore: NewMemoryStateStore()}
+	NewManager(b, e, store).Register(shipSaga{})
+
+	var persisted State
+	e.before = func(cmd command.Command) {
+		if cmd.CommandType() == chargeType {
+			persisted, _ = store.Load(context.Background(), "ship", "c-1")
+		}
+	}
+	place(b, "c-1")
+	b.drain(context.Background())
+	if persisted.Status != StatusRunning || len(persisted.Compensations) != 1 {
+		t.Error("the compensation of the first step should be persisted before the next step:", persisted)
+	}
+
+	// the save after the first step fails, its compensation is still executed
+	e.before = nil
+	store.fail = store.saves + 1
+	e.executed = nil
+	place(b, "c-2")
+	b.drain(context.Background())
+
+	if len(b.errs) != 1 || !errors.Is(b.errs[0], errSave) {
+		t.Error("the save error should be returned:", b.errs)
+	}
+	if len(e.executed) != 2 || e.executed[0] != reserveType || e.executed[1] != releaseType {
+		t.Error("the next steps should not be dispatched and the first one compensated:", e.executed)
+	}
+	if state, _ := store.Load(context.Background(), "ship", "c-2"); state.Status != StatusCompensated {
+		t.Error("the saga should be compensated:", state)
+	}
+}
+
+type placedData struct {
+	OrderID string
+}
+
+func TestManager_WithCorrelation(t *testing.T) {
+	b := &bus{}
+	e := &executer{bus: b}
+	store := NewMemoryStateStore()
+	NewManager(b, e, store, WithCorrelation(func(ev goevent.Event) (string, bool) {
+		data, ok := ev.Data().(*placedData)
+		if !ok {
+			return "", false
+		}
+		return data.OrderID, true
+	})).Register(orderSaga{})
+
+	// the event has no correlation metadata, e.g. it was received from another process
+	b.Publish(context.Background(), goevent.NewEvent(orderPlaced, &placedData{OrderID: "o-1"}))
+	b.drain(context.Background())
+
+	if len(b.errs) != 0 || len(e.executed) != 1 || e.executed[0] != reserveType {
+		t.Error("the saga should run on the correlation of the event data:", e.executed, b.errs)
+	}
+	if state, err := store.Load(context.Background(), "order", "o-1"); err != nil || state.Get("order") != "1" {
+		t.Error("the state should be stored with the correlation ID:", state, err)
+	}
+}
@@ -0,0 +1,119 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gapsquare/command"
+)
+
+// ErrNotFound error when the state of a saga instance does not exist
+var ErrNotFound = errors.New("Saga state not found")
+
+// Status is the status of a saga instance
+type Status string
+
+// Statuses of a saga instance
+const (
+	// StatusRunning the saga reacts to the events of its correlation ID
+	StatusRunning Status = "running"
+	// StatusCompleted the saga completed, its events are ignored
+	StatusCompleted Status = "completed"
+	// StatusCompensated a command failed and the compensating commands were executed
+	StatusCompensated Status = "compensated"
+	// StatusFailed a compensating command failed, the remaining compensations are kept in the state
+	StatusFailed Status = "failed"
+)
+
+// State is the persisted state of a saga instance
+type State struct {
+	SagaType Type `json:"saga_type"`
+	// ID is the correlation ID of the instance
+	ID     string `json:"id"`
+	Status Status `json:"status"`
+	// Data is the data of the saga, e.g. the IDs of the entities it handles
+	Data map[string]string `json:"data,omitempty"`
+	// Compensations are the compensating commands of the dispatched commands, executed in reverse order on failure
+	Compensations []command.Envelope `json:"compensations,omitempty"`
+	// Error is the error which stopped the saga
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Complete completes the saga, its next events are ignored
+func (s *State) Complete() {
+	s.Status = StatusCompleted
+}
+
+// Set sets a data value
+func (s *State) Set(key, value string) {
+	if s.Data == nil {
+		s.Data = map[string]string{}
+	}
+	s.Data[key] = value
+}
+
+// Get returns a data value
+func (s *State) Get(key string) string {
+	return s.Data[key]
+}
+
+// StateStore stores the state of the saga instances
+type StateStore interface {
+	// Load returns the state of an instance, ErrNotFound if it does not exist
+	Load(ctx context.Context, sagaType Type, id string) (State, error)
+	// Save saves the state of an instance
+	Save(context.Context, State) error
+}
+
+type stateKey struct {
+	sagaType Type
+	id       string
+}
+
+// MemoryStateStore is an in-memory StateStore
+type MemoryStateStore struct {
+	mu     sync.RWMutex
+	states map[stateKey]State
+}
+
+var _ = StateStore(&MemoryStateStore{})
+
+// NewMemoryStateStore creates an empty MemoryStateStore
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{states: make(map[stateKey]State)}
+}
+
+// Load implements the StateStore interface
+func (s *MemoryStateStore) Load(_ context.Context, sagaType Type, id string) (State, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state, ok := s.states[stateKey{sagaType, id}]
+	if !ok {
+		return State{}, ErrNotFound
+	}
+	return copyState(state), nil
+}
+
+// Save implements the StateStore interface
+func (s *MemoryStateStore) Save(_ context.Context, state State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[stateKey{state.SagaType, state.ID}] = copyState(state)
+	return nil
+}
+
+func copyState(state State) State {
+	if state.Data != nil {
+		data := make(map[string]string, len(state.Data))
+		for k, v := range state.Data {
+			data[k] = v
+		}
+		state.Data = data
+	}
+	state.Compensations = append([]command.Envelope(nil), state.Compensations...)
+	return state
+}
@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed cron schedule
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar are true if the field is *, the day matches both fields if one of them is *,
+	// and either of them otherwise
+	domStar, dowStar bool
+}
+
+type cronField struct {
+	min, max int
+}
+
+var cronFields = []cronField{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 6},  // day of week, 0 is sunday
+}
+
+// ParseCron parses a cron schedule of 5 fields: minute, hour, day of month, month and day of week.
+// A field is * or a list of values, ranges a-b and steps */n or a-b/n, e.g. "*/15 9-17 * * 1-5"
+func ParseCron(spec string) (Cron, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return Cron{}, fmt.Errorf("invalid cron schedule %q: expected %d fields", spec, len(cronFields))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, f := range fields {
+		b, err := parseCronField(f, cronFields[i])
+		if err != nil {
+			return Cron{}, fmt.Errorf("invalid cron schedule %q: %v", spec, err)
+		}
+		bits[i] = b
+	}
+
+	// 7 is also sunday
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return Cron{
+		minute: bits[0], hour: bits[1], dom: bits[2], month: bits[3], dow: bits[4],
+		domStar: fields[2] == "*", dowStar: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, r cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rng, step = part[:i], s
+		}
+
+		lo, hi := r.min, r.max
+		if r.max == 6 {
+			// day of week accepts 7 for sunday
+			hi = 7
+		}
+		switch {
+		case rng == "*":
+			hi = r.max
+		case strings.Contains(rng, "-"):
+			bounds := strings.SplitN(rng, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		default:
+			v, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", rng)
+			}
+			lo = v
+			if step == 1 {
+				hi = v
+			}
+		}
+
+		max := r.max
+		if r.max == 6 {
+			max = 7
+		}
+		if lo < r.min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, r.min, r.max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// maxCronIterations bounds the search of Next, schedules which never match return the zero time
+const maxCronIterations = 5 * 366 * 24
+
+// Next returns the first time of the schedule strictly after t, in the location of t
+func (c Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for i := 0; i < maxCronIterations; i++ {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCron_Next(t *testing.T) {
+	from := time.Date(2024, 1, 1, 10, 7, 30, 0, time.UTC) // a monday
+
+	for _, c := range []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 1, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 1, 10, 15, 0, 0, time.UTC)},
+		{"0 9-17 * * *", time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"30 8 * * 6,7", time.Date(2024, 1, 6, 8, 30, 0, 0, time.UTC)},
+		{"0 0 1 3 *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 15 * 3", time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	} {
+		cron, err := ParseCron(c.spec)
+		if err != nil {
+			t.Error("there should be no error:", c.spec, err)
+			continue
+		}
+		if next := cron.Next(from); !next.Equal(c.next) {
+			t.Error("unexpected next time of", c.spec, next)
+		}
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Error("the schedule should be invalid:", spec)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gapsquare/command"
+)
+
+// ErrorHandler is called with the jobs which fail, a job executed once is removed even if it fails.
+// Run also reports the errors of the store, with a zero Job
+type ErrorHandler func(context.Context, Job, error)
+
+// Option configures a Scheduler
+type Option func(*Scheduler)
+
+// WithClock sets the function returning the current time, useful in tests
+func WithClock(now func() time.Time) Option {
+	return func(s *Scheduler) {
+		s.now = now
+	}
+}
+
+// WithPollInterval sets the interval Run checks the due jobs at, default 1s
+func WithPollInterval(d time.Duration) Option {
+	return func(s *Scheduler) {
+		s.interval = d
+	}
+}
+
+// WithErrorHandler sets the ErrorHandler, e.g. to dead-letter the failed jobs
+func WithErrorHandler(fn ErrorHandler) Option {
+	return func(s *Scheduler) {
+		s.onError = fn
+	}
+}
+
+// Scheduler executes commands later, once or on a cron schedule
+type Scheduler struct {
+	store    Store
+	executer command.Executer
+	now      func() time.Time
+	interval time.Duration
+	onError  ErrorHandler
+}
+
+// NewScheduler creates a Scheduler storing the jobs in the store and executing them with the executer.
+// A single Scheduler should run per store, the jobs are not locked while executed
+func NewScheduler(store Store, executer command.Executer, options ...Option) *Scheduler {
+	s := &Scheduler{
+		store:    store,
+		executer: executer,
+		now:      time.Now,
+		interval: time.Second,
+		onError:  func(context.Context, Job, error) {},
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Schedule schedules the command to be executed once at the given time, with the metadata and the tenant of
+// the context. It returns the ID of the job
+func (s *Scheduler) Schedule(ctx context.Context, cmd command.Command, at time.Time) (string, error) {
+	return s.save(ctx, cmd, at, "")
+}
+
+// ScheduleCron schedules the command to be executed on the cron schedule, see ParseCron.
+// It returns the ID of the job
+func (s *Scheduler) ScheduleCron(ctx context.Context, cmd command.Command, spec string) (string, error) {
+	c, err := ParseCron(spec)
+	if err != nil {
+		return "", err
+	}
+
+	next := c.Next(s.now())
+	if next.IsZero() {
+		return "", fmt.Errorf("cron schedule %q never matches", spec)
+	}
+	return s.save(ctx, cmd, next, spec)
+}
+
+func (s *Scheduler) save(ctx context.Context, cmd command.Command, at time.Time, spec string) (string, error) {
+	envelope, err := command.NewEnvelope(ctx, cmd)
+	if err != nil {
+		return "", err
+	}
+
+	job := Job{ID: command.NewUUIDEntityID().String(), Envelope: envelope, At: at, Cron: spec}
+	if err := s.store.Save(ctx, job); err != nil {
+		return "", err
+	}
+	return job.ID, nil
+}
+
+// Cancel cancels a job, ErrNotFound if it does not exist
+func (s *Scheduler) Cancel(ctx context.Context, id string) error {
+	return s.store.Remove(ctx, id)
+}
+
+// Run executes the due jobs every poll interval until the context is done. The errors of the store are
+// reported to the ErrorHandler and the due jobs are checked again at the next poll
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunDue(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.onError(ctx, Job{}, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue executes the jobs due now. A job executed once is removed, a recurring job is rescheduled
+// at the next time of its schedule after now. It returns the errors of the store
+func (s *Scheduler) RunDue(ctx context.Context) error {
+	now := s.now()
+	jobs, err := s.store.Due(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		cmd, err := job.Envelope.Command()
+		if err == nil {
+			err = s.executer.Execute(job.Envelope.Context(ctx), cmd)
+		}
+		if err != nil {
+			s.onError(ctx, job, err)
+		}
+
+		if e := s.reschedule(ctx, job, now); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) reschedule(ctx context.Context, job Job, now time.Time) error {
+	if job.Cron == "" {
+		if err := s.store.Remove(ctx, job.ID); err != nil && err != ErrNotFound {
+			return err
+		}
+		return nil
+	}
+
+	c, err := ParseCron(job.Cron)
+	if err == nil {
+		if job.At = c.Next(now); job.At.IsZero() {
+			err = fmt.Errorf("cron schedule %q never matches", job.Cron)
+		}
+	}
+	if err != nil {
+		// the job can not be rescheduled
+		s.onError(ctx, job, err)
+		return s.store.Remove(ctx, job.ID)
+	}
+	return s.store.Save(ctx, job)
+}
@@ -0,0 +1,165 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gapsquare/command"
+
+	"github.com/gapsquare/command/mocks"
+)
+
+const expireType = command.Type("scheduler.expire")
+
+type expireCommand struct {
+	ID int `json:"id"`
+}
+
+func (c *expireCommand) CommandType() command.Type { return expireType }
+func (c *expireCommand) Entity() command.Entity    { return &mocks.SimpleModel{ID: c.ID} }
+
+func init() {
+	command.RegisterCommand(expireType, func() command.Command { return &expireCommand{} })
+}
+
+type executer struct {
+	err      error
+	executed []int
+	tenants  []command.TenantID
+}
+
+func (e *executer) Execute(ctx context.Context, cmd command.Command) error {
+	tenant, _ := command.TenantFromContext(ctx)
+	e.executed = append(e.executed, cmd.(*expireCommand).ID)
+	e.tenants = append(e.tenants, tenant)
+	return e.err
+}
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func TestScheduler_Schedule(t *testing.T) {
+	c := &clock{now: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	e := &executer{}
+	s := NewScheduler(NewMemoryStore(), e, WithClock(c.Now))
+	ctx := command.WithTenant(context.Background(), "acme")
+
+	s.Schedule(ctx, &expireCommand{ID: 2}, c.now.Add(15*time.Minute))
+	s.Schedule(ctx, &expireCommand{ID: 1}, c.now.Add(10*time.Minute))
+	cancelled, _ := s.Schedule(ctx, &expireCommand{ID: 3}, c.now.Add(10*time.Minute))
+
+	if err := s.Cancel(ctx, cancelled); err != nil {
+		t.Error("there should be no error:", err)
+	}
+	if err := s.Cancel(ctx, cancelled); err != ErrNotFound {
+		t.Error("there should be a not found error:", err)
+	}
+
+	s.RunDue(context.Background())
+	if len(e.executed) != 0 {
+		t.Error("the jobs should not be executed before they are due:", e.executed)
+	}
+
+	c.now = c.now.Add(20 * time.Minute)
+	s.RunDue(context.Background())
+	if len(e.executed) != 2 || e.executed[0] != 1 || e.executed[1] != 2 || e.tenants[0] != "acme" {
+		t.Error("the due jobs should be executed in order with their tenant:", e.executed, e.tenants)
+	}
+
+	s.RunDue(context.Background())
+	if len(e.executed) != 2 {
+		t.Error("the jobs should be executed once:", e.executed)
+	}
+}
+
+func TestScheduler_ScheduleCron(t *testing.T) {
+	c := &clock{now: time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC)}
+	e := &executer{err: errors.New("can not expire")}
+	var failed []Job
+	s := NewScheduler(NewMemoryStore(), e, WithClock(c.Now), WithErrorHandler(func(_ context.Context, job Job, err error) {
+		failed = append(failed, job)
+	}))
+
+	if _, err := s.ScheduleCron(context.Background(), &expireCommand{ID: 1}, "*/15 * * *"); err == nil {
+		t.Error("an invalid schedule should be rejected")
+	}
+	id, err := s.ScheduleCron(context.Background(), &expireCommand{ID: 1}, "*/15 * * * *")
+	if err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	for _, minutes := range []int{5, 10, 20} {
+		c.now = c.now.Add(time.Duration(minutes) * time.Minute)
+		s.RunDue(context.Background())
+	}
+	if len(e.executed) != 2 || len(failed) != 2 || failed[0].ID != id {
+		t.Error("the job should run at 10:15 and 10:45 and report its failures:", e.executed, failed)
+	}
+
+	s.Cancel(context.Background(), id)
+	c.now = c.now.Add(time.Hour)
+	s.RunDue(context.Background())
+	if len(e.executed) != 2 {
+		t.Error("a cancelled job should not run:", e.executed)
+	}
+}
+
+func TestScheduler_Run(t *testing.T) {
+	e := &executer{}
+	s := NewScheduler(NewMemoryStore(), e, WithPollInterval(time.Millisecond))
+	s.Schedule(context.Background(), &expireCommand{ID: 1}, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Run(ctx); err != context.DeadlineExceeded {
+		t.Error("run should stop with the context:", err)
+	}
+	if len(e.executed) != 1 {
+		t.Error("the due job should be executed:", e.executed)
+	}
+}
+
+// failingStore fails the first lookup of the due jobs
+type failingStore struct {
+	*MemoryStore
+	err error
+}
+
+func (s *failingStore) Due(ctx context.Context, t time.Time) ([]Job, error) {
+	if err := s.err; err != nil {
+		s.err = nil
+		return nil, err
+	}
+	return s.MemoryStore.Due(ctx, t)
+}
+
+func TestScheduler_RunReportsStoreErrors(t *testing.T) {
+	errStore := errors.New("store unavailable")
+	e := &executer{}
+	var reported []error
+	s := NewScheduler(&failingStore{MemoryStore: NewMemoryStore(), err: errStore}, e, WithPollInterval(time.Millisecond),
+		WithErrorHandler(func(_ context.Context, job Job, err error) {
+			if job.ID == "" {
+				reported = append(reported, err)
+			}
+		}))
+	s.Schedule(context.Background(), &expireCommand{ID: 1}, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Run(ctx); err != context.DeadlineExceeded {
+		t.Error("run should stop with the context only:", err)
+	}
+
+	if len(reported) != 1 || reported[0] != errStore {
+		t.Error("the store error should be reported:", reported)
+	}
+	if len(e.executed) != 1 {
+		t.Error("the due job should be executed at the next poll:", e.executed)
+	}
+}
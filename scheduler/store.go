@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gapsquare/command"
+)
+
+// ErrNotFound error when a scheduled job does not exist
+var ErrNotFound = errors.New("Scheduled job not found")
+
+// Job is a scheduled command
+type Job struct {
+	ID string `json:"id"`
+	// Envelope is the encoded command with its metadata
+	Envelope command.Envelope `json:"envelope"`
+	// At is the time of the next execution
+	At time.Time `json:"at"`
+	// Cron is the schedule of a recurring job, empty for a job executed once
+	Cron string `json:"cron,omitempty"`
+}
+
+// Store stores the scheduled jobs
+type Store interface {
+	// Save saves a job, it replaces the job with the same ID
+	Save(context.Context, Job) error
+	// Remove removes a job, ErrNotFound if it does not exist
+	Remove(ctx context.Context, id string) error
+	// Due returns the jobs due at t, the earliest first
+	Due(ctx context.Context, t time.Time) ([]Job, error)
+}
+
+// MemoryStore is an in-memory Store
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]Job
+}
+
+var _ = Store(&MemoryStore{})
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]Job)}
+}
+
+// Save implements the Store interface
+func (s *MemoryStore) Save(_ context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+// Remove implements the Store interface
+func (s *MemoryStore) Remove(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.jobs, id)
+	return nil
+}
+
+// Due implements the Store interface
+func (s *MemoryStore) Due(_ context.Context, t time.Time) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var jobs []Job
+	for _, job := range s.jobs {
+		if !job.At.After(t) {
+			jobs = append(jobs, job)
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].At.Equal(jobs[j].At) {
+			return jobs[i].ID < jobs[j].ID
+		}
+		return jobs[i].At.Before(jobs[j].At)
+	})
+	return jobs, nil
+}
@@ -0,0 +1,48 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Stage is a step of a command execution
+type Stage string
+
+// Stages of a command execution
+const (
+	StageHandlerLookup  Stage = "handler.lookup"
+	StageLoad           Stage = "load"
+	StageVersionCheck   Stage = "version.check"
+	StageHandle         Stage = "handle"
+	StageStoreSave      Stage = "store.save"
+	StageRepositorySave Stage = "repository.save"
+	StagePublish        Stage = "publish"
+)
+
+// TimeoutError error when the deadline of the context is exceeded during a stage of the execution
+type TimeoutError struct {
+	CommandType Type
+	Stage       Stage
+	Err         error
+}
+
+// Error implements the error interface
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("command %s timed out in stage %s: %v", e.CommandType, e.Stage, e.Err)
+}
+
+// Unwrap returns the underlying error, usually context.DeadlineExceeded
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// StageTimeout converts err to a *TimeoutError of the stage if the deadline is exceeded,
+// other errors, and errors which are already a *TimeoutError, are returned unchanged
+func StageTimeout(cmdType Type, stage Stage, err error) error {
+	var timeoutErr *TimeoutError
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) || errors.As(err, &timeoutErr) {
+		return err
+	}
+	return &TimeoutError{CommandType: cmdType, Stage: stage, Err: err}
+}
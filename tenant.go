@@ -0,0 +1,75 @@
+package command
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gapsquare/goevent"
+)
+
+// ErrTenantMissing error when tenant isolation is enabled and the context carries no tenant
+var ErrTenantMissing = errors.New("Tenant missing")
+
+// ErrTenantMismatch error when the loaded entity belongs to another tenant
+var ErrTenantMismatch = errors.New("Tenant mismatch")
+
+// MetadataTenantID is the event metadata key of the tenant
+const MetadataTenantID = "tenant_id"
+
+// TenantID identifies a tenant
+type TenantID string
+
+// TenantOwned is an entity belonging to a tenant
+type TenantOwned interface {
+	TenantID() TenantID
+}
+
+// TenantAwareRepository is a repository partitioned by tenant
+type TenantAwareRepository interface {
+	ReadWriteRepository
+
+	// ForTenant returns the repository of the tenant
+	ForTenant(TenantID) ReadWriteRepository
+}
+
+// TenantAwareStore is a command Store which stores the tenant with the command
+type TenantAwareStore interface {
+	Store
+
+	// SaveForTenant saves the command of the tenant
+	SaveForTenant(TenantID, Command, WriteRepository) error
+}
+
+type tenantContextKey int
+
+const tenantKey tenantContextKey = iota
+
+// WithTenant returns a context carrying the tenant
+func WithTenant(ctx context.Context, tenant TenantID) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// TenantFromContext returns the tenant carried by the context
+func TenantFromContext(ctx context.Context) (TenantID, bool) {
+	tenant, ok := ctx.Value(tenantKey).(TenantID)
+	return tenant, ok && tenant != ""
+}
+
+// EventTenant returns the tenant stamped on an event by the executer
+func EventTenant(ev goevent.Event) (TenantID, bool) {
+	tenant, ok := EventMetadata(ev)[MetadataTenantID]
+	return TenantID(tenant), ok
+}
+
+// checkTenant returns ErrTenantMismatch if the entity belongs to another tenant
+func checkTenant(ctx context.Context, entity Entity) error {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	if owned, ok := entity.(TenantOwned); ok && owned.TenantID() != tenant {
+		return ErrTenantMismatch
+	}
+	return nil
+}
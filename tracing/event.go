@@ -0,0 +1,37 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/goevent"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// EventEnricher returns an EventEnricher injecting the trace context into the metadata of the published events,
+// see command.WithEventEnricher. A nil propagator uses the global one
+func EventEnricher(propagator propagation.TextMapPropagator) command.EventEnricher {
+	return func(ctx context.Context, _ command.Command, ev goevent.Event) goevent.Event {
+		carrier := propagation.MapCarrier{}
+		propagatorOrGlobal(propagator).Inject(ctx, carrier)
+		for k, v := range carrier {
+			ev = command.EventWithMetadata(ev, k, v)
+		}
+		return ev
+	}
+}
+
+// ContextFromEvent returns ctx with the trace context injected in the metadata of the event,
+// event handlers use it to continue the trace. A nil propagator uses the global one
+func ContextFromEvent(ctx context.Context, propagator propagation.TextMapPropagator, ev goevent.Event) context.Context {
+	return propagatorOrGlobal(propagator).Extract(ctx, propagation.MapCarrier(command.EventMetadata(ev)))
+}
+
+func propagatorOrGlobal(propagator propagation.TextMapPropagator) propagation.TextMapPropagator {
+	if propagator == nil {
+		return otel.GetTextMapPropagator()
+	}
+	return propagator
+}
@@ -0,0 +1,109 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gapsquare/command"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer
+const instrumentationName = "github.com/gapsquare/command/tracing"
+
+// Span attributes of the commands
+const (
+	AttributeCommandType = attribute.Key("command.type")
+	AttributeEntityID    = attribute.Key("command.entity_id")
+	AttributeVersion     = attribute.Key("command.version")
+	AttributeStage       = attribute.Key("command.stage")
+)
+
+// spanNames are the names of the stage spans
+var spanNames = map[command.Stage]string{
+	command.StageHandlerLookup:  "handler.Lookup",
+	command.StageLoad:           "repository.Find",
+	command.StageVersionCheck:   "version.Check",
+	command.StageHandle:         "handler.HandleCommand",
+	command.StageStoreSave:      "store.Save",
+	command.StageRepositorySave: "repository.Save",
+	command.StagePublish:        "bus.Publish",
+}
+
+// Option configures the tracing
+type Option func(*observer)
+
+// WithTracerProvider sets the TracerProvider, default the global one
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *observer) {
+		o.provider = provider
+	}
+}
+
+type observer struct {
+	provider trace.TracerProvider
+	tracer   trace.Tracer
+}
+
+var _ = command.Observer(&observer{})
+
+// NewObserver returns an Observer starting a span per execution, with a child span per stage,
+// see command.WithObserver
+func NewObserver(options ...Option) command.Observer {
+	o := &observer{}
+	for _, option := range options {
+		option(o)
+	}
+	if o.provider == nil {
+		o.provider = otel.GetTracerProvider()
+	}
+	o.tracer = o.provider.Tracer(instrumentationName)
+	return o
+}
+
+// ObserveExecution implements the command.Observer interface
+func (o *observer) ObserveExecution(ctx context.Context, cmd command.Command) (context.Context, func(error)) {
+	ctx, span := o.tracer.Start(ctx, "command.Execute", trace.WithAttributes(commandAttributes(cmd)...))
+	return ctx, end(span)
+}
+
+// ObserveStage implements the command.Observer interface
+func (o *observer) ObserveStage(ctx context.Context, cmd command.Command, stage command.Stage) (context.Context, func(error)) {
+	name, ok := spanNames[stage]
+	if !ok {
+		name = string(stage)
+	}
+
+	attrs := append(commandAttributes(cmd), AttributeStage.String(string(stage)))
+	ctx, span := o.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, end(span)
+}
+
+func end(span trace.Span) func(error) {
+	return func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+func commandAttributes(cmd command.Command) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{AttributeCommandType.String(string(cmd.CommandType()))}
+
+	if entity := cmd.Entity(); entity != nil {
+		attrs = append(attrs, AttributeEntityID.String(entity.EntityID().String()))
+	}
+
+	if v, ok := cmd.(command.Versionable); ok {
+		attrs = append(attrs, AttributeVersion.Int64(int64(v.Version())))
+	} else if token, ok := command.ConcurrencyTokenOf(cmd); ok {
+		attrs = append(attrs, AttributeVersion.String(fmt.Sprint(token)))
+	}
+	return attrs
+}
@@ -0,0 +1,182 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/goevent"
+
+	"github.com/gapsquare/command/mocks"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const renameType = command.Type("tracing.rename")
+
+type renameCommand struct {
+	ID  int
+	Ver command.VersionType
+}
+
+func (c *renameCommand) CommandType() command.Type    { return renameType }
+func (c *renameCommand) Entity() command.Entity       { return &mocks.MockVersionableModel{ID: c.ID} }
+func (c *renameCommand) Version() command.VersionType { return c.Ver }
+func (c *renameCommand) Events(ctx context.Context) goevent.Events {
+	return goevent.Events{goevent.NewEvent("renamed", nil)}
+}
+
+func newExecuter(t *testing.T, bus goevent.EventBus) (command.Executer, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	ce, err := command.NewExecuter(command.BuildConfiguration(
+		command.WithCommandStore(mocks.MockCommandStore{}),
+		command.WithEventBus(bus),
+		command.WithObserver(NewObserver(WithTracerProvider(provider))),
+		command.WithEventEnricher(EventEnricher(propagation.TraceContext{})),
+	), &mocks.MockRepository{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ce, exporter
+}
+
+func TestObserver_Spans(t *testing.T) {
+	command.RegisterCommandHandler(renameType, &mocks.MockCommandHandler{})
+	defer command.UnRegisterCommandHandler(renameType)
+
+	bus := &mocks.EventBus{}
+	ce, exporter := newExecuter(t, bus)
+	if err := ce.Execute(context.Background(), &renameCommand{ID: 1}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	spans := exporter.GetSpans()
+	root := spans[len(spans)-1]
+	if root.Name != "command.Execute" || root.Parent.IsValid() {
+		t.Fatal("the execution span should be the root span:", root.Name)
+	}
+
+	names := map[string]bool{}
+	for _, span := range spans[:len(spans)-1] {
+		names[span.Name] = true
+		if span.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Error("the stage spans should be children of the execution span:", span.Name)
+		}
+	}
+	for _, name := range []string{"repository.Find", "handler.HandleCommand", "store.Save", "repository.Save", "bus.Publish"} {
+		if !names[name] {
+			t.Error("there should be a span", name)
+		}
+	}
+
+	attrs := map[string]string{}
+	for _, kv := range root.Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs["command.type"] != string(renameType) || attrs["command.entity_id"] != "1" || attrs["command.version"] != "0" {
+		t.Error("the span should describe the command:", attrs)
+	}
+
+	ctx := ContextFromEvent(context.Background(), propagation.TraceContext{}, bus.Events[0])
+	if sc := trace.SpanContextFromContext(ctx); sc.TraceID() != root.SpanContext.TraceID() {
+		t.Error("the trace context should be injected in the event metadata:", command.EventMetadata(bus.Events[0]))
+	}
+}
+
+func TestObserver_RecordsErrors(t *testing.T) {
+	errHandle := errors.New("can not handle")
+	command.RegisterCommandHandler(renameType, &mocks.MockCommandHandler{Err: errHandle})
+	defer command.UnRegisterCommandHandler(renameType)
+
+	ce, exporter := newExecuter(t, &mocks.EventBus{})
+	if err := ce.Execute(context.Background(), &renameCommand{ID: 1}); err != errHandle {
+		t.Fatal("the handler error should be returned:", err)
+	}
+
+	failed := map[string]bool{}
+	for _, span := range exporter.GetSpans() {
+		if span.Status.Code == codes.Error {
+			failed[span.Name] = len(span.Events) == 1 && span.Events[0].Name == "exception"
+		}
+	}
+	if len(failed) != 2 || !failed["handler.HandleCommand"] || !failed["command.Execute"] {
+		t.Error("the handle and execution spans should record the error:", failed)
+	}
+}
+
+// serializingBus publishes the events as json like a bus to another process
+type serializingBus struct {
+	mocks.EventBus
+}
+
+func (b *serializingBus) Publish(ctx context.Context, ev goevent.Event) error {
+	envelope, err := command.NewEventEnvelope(ev)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	var received command.EventEnvelope
+	if err := json.Unmarshal(payload, &received); err != nil {
+		return err
+	}
+	if ev, err = received.Event(); err != nil {
+		return err
+	}
+	return b.EventBus.Publish(ctx, ev)
+}
+
+type renamedData struct {
+	Name string
+}
+
+func TestEventEnricher_SerializingBus(t *testing.T) {
+	command.RegisterCommandHandler(renameType, &mocks.MockCommandHandler{})
+	defer command.UnRegisterCommandHandler(renameType)
+
+	bus := &serializingBus{}
+	ce, exporter := newExecuter(t, bus)
+	if err := ce.Execute(context.Background(), &renameCommand{ID: 1}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+
+	spans := exporter.GetSpans()
+	root := spans[len(spans)-1]
+	ctx := ContextFromEvent(context.Background(), propagation.TraceContext{}, bus.Events[0])
+	if sc := trace.SpanContextFromContext(ctx); sc.TraceID() != root.SpanContext.TraceID() {
+		t.Error("the trace context should be kept by the serialized event:", command.EventMetadata(bus.Events[0]))
+	}
+
+	const topic = goevent.EventTopic("tracing.renamed")
+	if err := goevent.RegisterEventData(topic, func() goevent.EventData { return &renamedData{} }); err != nil {
+		t.Fatal(err)
+	}
+	defer goevent.UnRegisterEventData(topic)
+
+	ev := command.EventWithMetadata(goevent.NewEvent(topic, &renamedData{Name: "renamed"}), "key", "value")
+	if err := bus.Publish(context.Background(), ev); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	received := bus.Events[1]
+	if data, _ := received.Data().(*renamedData); data == nil || data.Name != "renamed" || received.Topic() != topic {
+		t.Error("the event data should be decoded:", received)
+	}
+	if command.EventMetadata(received)["key"] != "value" {
+		t.Error("the metadata should be kept:", command.EventMetadata(received))
+	}
+	if _, ok := command.UnwrapEvent(received).(command.MetadataEvent); ok {
+		t.Error("the unwrapped event should have no metadata")
+	}
+}
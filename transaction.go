@@ -0,0 +1,39 @@
+package command
+
+import "context"
+
+// Transaction is a repository bound to a unit of work
+type Transaction interface {
+	ReadWriteRepository
+
+	// Commit commits the transaction
+	Commit() error
+
+	// Rollback aborts the transaction
+	Rollback() error
+}
+
+// TransactionalRepository is a repository which can run a command execution in a transaction.
+// The executer finds and saves the entity and saves the command in the same transaction,
+// so a Store can join it through the WriteRepository it receives
+type TransactionalRepository interface {
+	ReadWriteRepository
+
+	// BeginTransaction starts a new transaction
+	BeginTransaction(context.Context) (Transaction, error)
+}
+
+type transactionContextKey int
+
+const transactionKey transactionContextKey = iota
+
+// TransactionFromContext returns the transaction of the running command execution,
+// it allows handlers to join the executer's transaction
+func TransactionFromContext(ctx context.Context) (Transaction, bool) {
+	tx, ok := ctx.Value(transactionKey).(Transaction)
+	return tx, ok
+}
+
+func withTransaction(ctx context.Context, tx Transaction) context.Context {
+	return context.WithValue(ctx, transactionKey, tx)
+}
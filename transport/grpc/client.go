@@ -0,0 +1,39 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/gapsquare/command"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Client is an Executer forwarding the commands to a remote CommandService
+type Client struct {
+	conn grpc.ClientConnInterface
+	opts []grpc.CallOption
+}
+
+var _ = command.Executer(&Client{})
+
+// NewClient creates a Client calling the CommandService over the connection, with the additional call options
+func NewClient(conn grpc.ClientConnInterface, opts ...grpc.CallOption) *Client {
+	return &Client{conn: conn, opts: opts}
+}
+
+// Execute sends the command with the metadata and the tenant of the context, the deadline of the context
+// is the deadline of the call. Errors of the remote execution are returned as an *Error
+func (c *Client) Execute(ctx context.Context, cmd command.Command) error {
+	envelope, err := command.NewEnvelope(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	var trailer metadata.MD
+	opts := append([]grpc.CallOption{grpc.CallContentSubtype(codecName), grpc.Trailer(&trailer)}, c.opts...)
+	if err := c.conn.Invoke(ctx, executeMethod, &envelope, &Result{}, opts...); err != nil {
+		return fromStatus(err, trailer)
+	}
+	return nil
+}
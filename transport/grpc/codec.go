@@ -0,0 +1,22 @@
+package grpc
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// codecName is the content-subtype of the json codec, the messages are sent as application/grpc+command-json.
+// It is specific to the package so it does not replace another json codec of the process
+const codecName = "command-json"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec is a grpc codec encoding the messages in json
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (codec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (codec) Name() string                               { return codecName }
@@ -0,0 +1,135 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/command/middleware/authz"
+	"github.com/gapsquare/command/middleware/circuitbreaker"
+	"github.com/gapsquare/command/middleware/ratelimit"
+	"github.com/gapsquare/command/middleware/validator"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// reasonKey is the trailer key of the reason of an error, the client uses it to return the matching error
+const reasonKey = "command-error-reason"
+
+// reasons are the errors sent to the clients by their reason
+var reasons = map[string]error{
+	"entity_not_found":       command.ErrEntityNotFound,
+	"command_not_registered": command.ErrCommandNotRegistered,
+	"handler_not_registered": command.ErrCommandHandlerNotRegistered,
+	"version_mismatched":     command.ErrVersionMismatched,
+	"concurrency_conflict":   command.ErrConcurrencyConflict,
+	"tenant_missing":         command.ErrTenantMissing,
+	"tenant_mismatch":        command.ErrTenantMismatch,
+	"unauthorized":           authz.ErrUnauthorized,
+	"forbidden":              authz.ErrForbidden,
+	"rate_limited":           ratelimit.ErrRateLimited,
+	"circuit_open":           circuitbreaker.ErrOpen,
+}
+
+// Error is an error returned by a remote execution, it wraps the error matching its reason if it has one
+type Error struct {
+	Code    codes.Code
+	Message string
+	Reason  string
+	Err     error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("remote execution failed: %s: %s", e.Code, e.Message)
+}
+
+// Unwrap returns the error matching the reason
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// GRPCStatus returns the status of the error
+func (e *Error) GRPCStatus() *status.Status {
+	return status.New(e.Code, e.Message)
+}
+
+// Code returns the grpc status code of an error returned by an execution
+func Code(err error) codes.Code {
+	var (
+		validationErrs  validator.ValidationErrors
+		fieldErr        validator.FieldError
+		preconditionErr *command.PreconditionError
+		timeoutErr      *command.TimeoutError
+	)
+
+	switch {
+	case errors.As(err, &validationErrs), errors.As(err, &fieldErr), errors.Is(err, command.ErrTenantMissing):
+		return codes.InvalidArgument
+	case errors.Is(err, authz.ErrUnauthorized):
+		return codes.Unauthenticated
+	case errors.Is(err, authz.ErrForbidden), errors.Is(err, command.ErrTenantMismatch):
+		return codes.PermissionDenied
+	case errors.Is(err, command.ErrEntityNotFound), errors.Is(err, command.ErrCommandNotRegistered),
+		errors.Is(err, command.ErrCommandHandlerNotRegistered):
+		return codes.NotFound
+	case errors.Is(err, command.ErrVersionMismatched), errors.Is(err, command.ErrConcurrencyConflict):
+		return codes.Aborted
+	case errors.As(err, &preconditionErr):
+		return codes.FailedPrecondition
+	case errors.Is(err, ratelimit.ErrRateLimited):
+		return codes.ResourceExhausted
+	case errors.Is(err, circuitbreaker.ErrOpen):
+		return codes.Unavailable
+	case errors.As(err, &timeoutErr), errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	}
+	return codes.Unknown
+}
+
+// toStatus converts an execution error to a status error, with its reason in the trailer.
+// The messages of unknown errors are not sent
+func toStatus(ctx context.Context, err error) error {
+	code := Code(err)
+	msg := err.Error()
+	if code == codes.Unknown {
+		code, msg = codes.Internal, "internal error"
+	}
+
+	for reason, e := range reasons {
+		if errors.Is(err, e) {
+			grpc.SetTrailer(ctx, metadata.Pairs(reasonKey, reason))
+			break
+		}
+	}
+	return status.Error(code, msg)
+}
+
+// fromStatus converts an error returned by a call to an *Error
+func fromStatus(err error, trailer metadata.MD) error {
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	e := &Error{Code: s.Code(), Message: s.Message()}
+	if values := trailer.Get(reasonKey); len(values) > 0 {
+		e.Reason = values[0]
+		e.Err = reasons[e.Reason]
+	}
+	if e.Err == nil {
+		switch e.Code {
+		case codes.DeadlineExceeded:
+			e.Err = context.DeadlineExceeded
+		case codes.Canceled:
+			e.Err = context.Canceled
+		}
+	}
+	return e
+}
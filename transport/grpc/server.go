@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/gapsquare/command"
+
+	"google.golang.org/grpc"
+)
+
+// ContextFunc returns the context of the execution of a call, e.g. with the principal authenticated from its metadata
+type ContextFunc func(context.Context) context.Context
+
+// ServerOption configures the server
+type ServerOption func(*Server)
+
+// WithBefore adds functions called, in order, to build the context of the executions
+func WithBefore(fns ...ContextFunc) ServerOption {
+	return func(s *Server) {
+		s.before = append(s.before, fns...)
+	}
+}
+
+// WithTrustedTenant applies the tenant sent by the clients to the executions. By default it is dropped,
+// since any caller could send the tenant of another one, and the tenant should be set by a WithBefore function
+// from the authenticated metadata of the call
+func WithTrustedTenant() ServerOption {
+	return func(s *Server) {
+		s.trustedTenant = true
+	}
+}
+
+// Server is the CommandService executing the received commands with an Executer
+type Server struct {
+	executer      command.Executer
+	before        []ContextFunc
+	trustedTenant bool
+}
+
+var _ = commandService(&Server{})
+
+// NewServer creates a Server executing the commands with the executer
+func NewServer(executer command.Executer, options ...ServerOption) *Server {
+	s := &Server{executer: executer}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Register registers the CommandService on the grpc server
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	registrar.RegisterService(&serviceDesc, s)
+}
+
+// Execute decodes the command of the envelope and executes it with the metadata of the envelope,
+// and the deadline of the call. Errors are returned as status errors
+func (s *Server) Execute(ctx context.Context, envelope *command.Envelope) (*Result, error) {
+	if _, ok := envelope.Metadata[command.MetadataTenantID]; ok && !s.trustedTenant {
+		metadata := make(command.Metadata, len(envelope.Metadata))
+		for k, v := range envelope.Metadata {
+			metadata[k] = v
+		}
+		delete(metadata, command.MetadataTenantID)
+		envelope.Metadata = metadata
+	}
+
+	ctx = envelope.Context(ctx)
+	for _, fn := range s.before {
+		ctx = fn(ctx)
+	}
+
+	cmd, err := envelope.Command()
+	if err == nil {
+		err = s.executer.Execute(ctx, cmd)
+	}
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &Result{CommandType: envelope.Type}, nil
+}
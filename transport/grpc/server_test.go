@@ -0,0 +1,147 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/command/middleware/validator"
+
+	"github.com/gapsquare/command/mocks"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const renameType = command.Type("grpc.rename")
+
+type renameCommand struct {
+	ID      int    `json:"id"`
+	Content string `json:"content"`
+}
+
+func (c *renameCommand) CommandType() command.Type { return renameType }
+func (c *renameCommand) Entity() command.Entity    { return &mocks.SimpleModel{ID: c.ID} }
+
+func init() {
+	command.RegisterCommand(renameType, func() command.Command { return &renameCommand{} })
+}
+
+type executerFunc func(context.Context, command.Command) error
+
+func (f executerFunc) Execute(ctx context.Context, cmd command.Command) error { return f(ctx, cmd) }
+
+func newClient(t *testing.T, executer command.Executer, options ...ServerOption) *Client {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	NewServer(executer, options...).Register(s)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewClient(conn)
+}
+
+func TestClient_Executes(t *testing.T) {
+	var executed *renameCommand
+	var correlationID string
+	var tenant command.TenantID
+	var deadline time.Time
+	client := newClient(t, executerFunc(func(ctx context.Context, cmd command.Command) error {
+		executed = cmd.(*renameCommand)
+		correlationID, _ = command.CorrelationID(ctx)
+		tenant, _ = command.TenantFromContext(ctx)
+		deadline, _ = ctx.Deadline()
+		return nil
+	}), WithTrustedTenant())
+
+	ctx := command.WithTenant(command.WithCorrelationID(context.Background(), "corr-1"), "acme")
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	if err := client.Execute(ctx, &renameCommand{ID: 1, Content: "renamed"}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if executed == nil || executed.ID != 1 || executed.Content != "renamed" {
+		t.Error("the command should be decoded:", executed)
+	}
+	if correlationID != "corr-1" || tenant != "acme" {
+		t.Error("the metadata should be propagated:", correlationID, tenant)
+	}
+	if deadline.IsZero() {
+		t.Error("the deadline should be propagated")
+	}
+}
+
+func TestServer_DropsClientTenant(t *testing.T) {
+	var tenant command.TenantID
+	var hasTenant bool
+	var correlationID string
+	executer := executerFunc(func(ctx context.Context, cmd command.Command) error {
+		tenant, hasTenant = command.TenantFromContext(ctx)
+		correlationID, _ = command.CorrelationID(ctx)
+		return nil
+	})
+
+	ctx := command.WithTenant(command.WithCorrelationID(context.Background(), "corr-1"), "other-tenant")
+	if err := newClient(t, executer).Execute(ctx, &renameCommand{ID: 1}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if hasTenant || correlationID != "corr-1" {
+		t.Error("the tenant sent by the client should not be applied:", tenant, correlationID)
+	}
+
+	authenticated := WithBefore(func(ctx context.Context) context.Context { return command.WithTenant(ctx, "acme") })
+	if err := newClient(t, executer, authenticated).Execute(ctx, &renameCommand{ID: 1}); err != nil {
+		t.Fatal("there should be no error:", err)
+	}
+	if tenant != "acme" {
+		t.Error("the tenant of the before functions should be applied:", tenant)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	for _, c := range []struct {
+		err  error
+		code codes.Code
+		is   error
+	}{
+		{command.ErrVersionMismatched, codes.Aborted, command.ErrVersionMismatched},
+		{command.ErrEntityNotFound, codes.NotFound, command.ErrEntityNotFound},
+		{validator.ValidationErrors{{Field: "content", Code: validator.CodeRequired}}, codes.InvalidArgument, nil},
+		{&command.TimeoutError{Stage: command.StageHandle, Err: context.DeadlineExceeded}, codes.DeadlineExceeded, context.DeadlineExceeded},
+		{errors.New("database password is wrong"), codes.Internal, nil},
+	} {
+		client := newClient(t, executerFunc(func(context.Context, command.Command) error { return c.err }))
+
+		err := client.Execute(context.Background(), &renameCommand{ID: 1})
+		var remoteErr *Error
+		if !errors.As(err, &remoteErr) || status.Code(err) != c.code {
+			t.Error("unexpected error of", c.err, err)
+			continue
+		}
+		if c.is != nil && !errors.Is(err, c.is) {
+			t.Error("the error should match", c.is, err)
+		}
+		if c.code == codes.Internal && remoteErr.Message != "internal error" {
+			t.Error("the internal errors should not be sent:", remoteErr)
+		}
+	}
+
+	client := newClient(t, executerFunc(func(context.Context, command.Command) error { return nil }))
+	if err := client.Execute(context.Background(), mocks.Command{}); !errors.Is(err, command.ErrCommandNotRegistered) {
+		t.Error("unregistered commands should not be found:", err)
+	}
+}
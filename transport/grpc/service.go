@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/gapsquare/command"
+
+	"google.golang.org/grpc"
+)
+
+// Service and method names of the CommandService
+const (
+	ServiceName   = "command.CommandService"
+	executeMethod = "/" + ServiceName + "/Execute"
+)
+
+// Result is the result of a successful execution
+type Result struct {
+	CommandType command.Type `json:"command_type"`
+}
+
+// commandService is the interface of the CommandService implementation
+type commandService interface {
+	Execute(context.Context, *command.Envelope) (*Result, error)
+}
+
+// serviceDesc describes the CommandService, the messages are encoded with the json codec
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*commandService)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Execute",
+			Handler:    executeHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+func executeHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(command.Envelope)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(commandService).Execute(ctx, in)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: executeMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(commandService).Execute(ctx, req.(*command.Envelope))
+	}
+	return interceptor(ctx, in, info, handler)
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/command/middleware/authz"
+	"github.com/gapsquare/command/middleware/circuitbreaker"
+	"github.com/gapsquare/command/middleware/ratelimit"
+	"github.com/gapsquare/command/middleware/validator"
+)
+
+// ErrorResponse is the json body of the error responses
+type ErrorResponse struct {
+	Error string `json:"error"`
+	// Fields are the violations of a validation error
+	Fields validator.ValidationErrors `json:"fields,omitempty"`
+}
+
+// ErrorEncoder writes the response of a failed request
+type ErrorEncoder func(context.Context, error, http.ResponseWriter)
+
+// decodeError is an error decoding the request body
+type decodeError struct {
+	err error
+}
+
+func (e decodeError) Error() string { return "invalid request body: " + e.err.Error() }
+func (e decodeError) Unwrap() error { return e.err }
+
+// StatusCode returns the http status code of an error returned by an execution
+func StatusCode(err error) int {
+	var (
+		decodeErr       decodeError
+		validationErrs  validator.ValidationErrors
+		fieldErr        validator.FieldError
+		preconditionErr *command.PreconditionError
+		timeoutErr      *command.TimeoutError
+		maxBytesErr     *http.MaxBytesError
+	)
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	case errors.As(err, &decodeErr), errors.As(err, &validationErrs), errors.As(err, &fieldErr),
+		errors.Is(err, command.ErrTenantMissing):
+		return http.StatusBadRequest
+	case errors.Is(err, authz.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, authz.ErrForbidden), errors.Is(err, command.ErrTenantMismatch):
+		return http.StatusForbidden
+	case errors.Is(err, command.ErrEntityNotFound), errors.Is(err, command.ErrCommandNotRegistered),
+		errors.Is(err, command.ErrCommandHandlerNotRegistered):
+		return http.StatusNotFound
+	case errors.Is(err, command.ErrVersionMismatched), errors.Is(err, command.ErrConcurrencyConflict):
+		return http.StatusConflict
+	case errors.As(err, &preconditionErr):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ratelimit.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, circuitbreaker.ErrOpen):
+		return http.StatusServiceUnavailable
+	case errors.As(err, &timeoutErr), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// retryAfter returns the retry after hint of an error, zero if it has none
+func retryAfter(err error) time.Duration {
+	var rateErr *ratelimit.Error
+	if errors.As(err, &rateErr) {
+		return rateErr.RetryAfter
+	}
+
+	var openErr *circuitbreaker.Error
+	if errors.As(err, &openErr) {
+		return openErr.RetryAfter
+	}
+	return 0
+}
+
+// DefaultErrorEncoder writes the status code of the error with an ErrorResponse, the messages of
+// internal errors are not written. The retry after hint of rate limit and circuit breaker errors
+// is written in the Retry-After header
+func DefaultErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	status := StatusCode(err)
+
+	response := ErrorResponse{Error: err.Error()}
+	if status == http.StatusInternalServerError {
+		response.Error = http.StatusText(status)
+	}
+
+	var validationErrs validator.ValidationErrors
+	var fieldErr validator.FieldError
+	if errors.As(err, &validationErrs) {
+		response.Fields = validationErrs
+	} else if errors.As(err, &fieldErr) {
+		response.Fields = validator.ValidationErrors{fieldErr}
+	}
+
+	if d := retryAfter(err); d > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
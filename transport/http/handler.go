@@ -0,0 +1,138 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gapsquare/command"
+)
+
+// Headers read into the metadata of the executions
+const (
+	HeaderCorrelationID  = "X-Correlation-ID"
+	HeaderIdempotencyKey = "Idempotency-Key"
+)
+
+// defaultMaxBodySize is the default maximum size of a request body
+const defaultMaxBodySize = 1 << 20
+
+// RequestFunc returns the context of the execution of a request, e.g. with its principal or tenant
+type RequestFunc func(context.Context, *http.Request) context.Context
+
+// Option configures the handler
+type Option func(*handler)
+
+// WithPrefix sets the path prefix of the commands, default "/commands/"
+func WithPrefix(prefix string) Option {
+	return func(h *handler) {
+		if !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+		h.prefix = prefix
+	}
+}
+
+// WithBefore adds functions called, in order, to build the context of the executions
+func WithBefore(fns ...RequestFunc) Option {
+	return func(h *handler) {
+		h.before = append(h.before, fns...)
+	}
+}
+
+// WithErrorEncoder sets the ErrorEncoder, default DefaultErrorEncoder
+func WithErrorEncoder(encoder ErrorEncoder) Option {
+	return func(h *handler) {
+		h.errorEncoder = encoder
+	}
+}
+
+// WithMaxBodySize sets the maximum size of a request body, default 1MB
+func WithMaxBodySize(n int64) Option {
+	return func(h *handler) {
+		h.maxBodySize = n
+	}
+}
+
+type handler struct {
+	executer     command.Executer
+	prefix       string
+	before       []RequestFunc
+	errorEncoder ErrorEncoder
+	maxBodySize  int64
+}
+
+// NewHandler returns an http.Handler executing the commands posted to POST {prefix}{type}. The command is created
+// by the factory registered with command.RegisterCommand and decoded from the json body. The correlation ID and
+// idempotency key headers are added to the metadata of the execution. It responds 204 No Content on success
+func NewHandler(executer command.Executer, options ...Option) http.Handler {
+	h := &handler{
+		executer:     executer,
+		prefix:       "/commands/",
+		errorEncoder: DefaultErrorEncoder,
+		maxBodySize:  defaultMaxBodySize,
+	}
+	for _, option := range options {
+		option(h)
+	}
+	return h
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.EscapedPath(), h.prefix) {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	if id := r.Header.Get(HeaderCorrelationID); id != "" {
+		ctx = command.WithCorrelationID(ctx, id)
+		w.Header().Set(HeaderCorrelationID, id)
+	}
+	if key := r.Header.Get(HeaderIdempotencyKey); key != "" {
+		ctx = command.WithMetadata(ctx, command.MetadataIdempotencyKey, key)
+	}
+	for _, fn := range h.before {
+		ctx = fn(ctx, r)
+	}
+
+	cmd, err := h.decode(w, r)
+	if err == nil {
+		err = h.executer.Execute(ctx, cmd)
+	}
+	if err != nil {
+		h.errorEncoder(ctx, err, w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decode creates the command of the path and decodes the request body into it
+func (h *handler) decode(w http.ResponseWriter, r *http.Request) (command.Command, error) {
+	path := strings.TrimPrefix(r.URL.EscapedPath(), h.prefix)
+	cmdType, err := url.PathUnescape(path)
+	if err != nil || cmdType == "" || strings.Contains(path, "/") {
+		return nil, command.ErrCommandNotRegistered
+	}
+
+	cmd, err := command.CreateCommand(command.Type(cmdType))
+	if err != nil {
+		return nil, err
+	}
+
+	body := http.MaxBytesReader(w, r.Body, h.maxBodySize)
+	if err := json.NewDecoder(body).Decode(cmd); err != nil && err != io.EOF {
+		return nil, decodeError{err}
+	}
+	return cmd, nil
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gapsquare/command"
+	"github.com/gapsquare/command/middleware/authz"
+	"github.com/gapsquare/command/middleware/circuitbreaker"
+	"github.com/gapsquare/command/middleware/ratelimit"
+	"github.com/gapsquare/command/middleware/validator"
+
+	"github.com/gapsquare/command/mocks"
+)
+
+const renameType = command.Type("http.rename")
+
+type renameCommand struct {
+	ID      int    `json:"id"`
+	Content string `json:"content"`
+}
+
+func (c *renameCommand) CommandType() command.Type { return renameType }
+func (c *renameCommand) Entity() command.Entity    { return &mocks.SimpleModel{ID: c.ID} }
+
+func init() {
+	command.RegisterCommand(renameType, func() command.Command { return &renameCommand{} })
+}
+
+type executerFunc func(context.Context, command.Command) error
+
+func (f executerFunc) Execute(ctx context.Context, cmd command.Command) error { return f(ctx, cmd) }
+
+func post(h http.Handler, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandler_Executes(t *testing.T) {
+	var executed *renameCommand
+	var metadata command.Metadata
+	h := NewHandler(executerFunc(func(ctx context.Context, cmd command.Command) error {
+		executed = cmd.(*renameCommand)
+		metadata = command.MetadataFromContext(ctx)
+		if _, ok := command.TenantFromContext(ctx); !ok {
+			t.Error("the before functions should build the context")
+		}
+		return nil
+	}), WithBefore(func(ctx context.Context, r *http.Request) context.Context {
+		return command.WithTenant(ctx, command.TenantID(r.Header.Get("X-Tenant")))
+	}))
+
+	w := post(h, "/commands/http.rename", `{"id":1,"content":"renamed"}`, map[string]string{
+		HeaderCorrelationID:  "corr-1",
+		HeaderIdempotencyKey: "key-1",
+		"X-Tenant":           "acme",
+	})
+	if w.Code != http.StatusNoContent {
+		t.Fatal("the response should be no content:", w.Code, w.Body.String())
+	}
+	if executed == nil || executed.ID != 1 || executed.Content != "renamed" {
+		t.Error("the command should be decoded:", executed)
+	}
+	if metadata[command.MetadataCorrelationID] != "corr-1" || metadata[command.MetadataIdempotencyKey] != "key-1" {
+		t.Error("the headers should be added to the metadata:", metadata)
+	}
+	if w.Header().Get(HeaderCorrelationID) != "corr-1" {
+		t.Error("the correlation ID should be returned")
+	}
+}
+
+func TestHandler_Requests(t *testing.T) {
+	h := NewHandler(executerFunc(func(context.Context, command.Command) error { return nil }))
+
+	for _, c := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodGet, "/commands/http.rename", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/commands/unknown", "{}", http.StatusNotFound},
+		{http.MethodPost, "/commands/", "{}", http.StatusNotFound},
+		{http.MethodPost, "/commands/http.rename/other", "{}", http.StatusNotFound},
+		{http.MethodPost, "/other/http.rename", "{}", http.StatusNotFound},
+		{http.MethodPost, "/commands/http.rename", "{invalid", http.StatusBadRequest},
+		{http.MethodPost, "/commands/http.rename", "", http.StatusNoContent},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(c.method, c.path, strings.NewReader(c.body)))
+		if w.Code != c.status {
+			t.Error("unexpected status of", c.method, c.path, c.body, w.Code)
+		}
+	}
+
+	h = NewHandler(executerFunc(func(context.Context, command.Command) error { return nil }), WithMaxBodySize(8))
+	if w := post(h, "/commands/http.rename", `{"content":"too long"}`, nil); w.Code != http.StatusRequestEntityTooLarge {
+		t.Error("a too large body should be rejected:", w.Code)
+	}
+}
+
+func TestHandler_ErrorStatusCodes(t *testing.T) {
+	for _, c := range []struct {
+		err    error
+		status int
+	}{
+		{validator.ValidationErrors{{Field: "content", Code: validator.CodeRequired, Message: "is required"}}, http.StatusBadRequest},
+		{&authz.Error{Err: authz.ErrUnauthorized}, http.StatusUnauthorized},
+		{&authz.Error{Err: authz.ErrForbidden}, http.StatusForbidden},
+		{command.ErrEntityNotFound, http.StatusNotFound},
+		{command.ErrVersionMismatched, http.StatusConflict},
+		{command.ErrConcurrencyConflict, http.StatusConflict},
+		{&command.PreconditionError{CommandType: renameType, Err: errors.New("cancelled")}, http.StatusUnprocessableEntity},
+		{&ratelimit.Error{CommandType: renameType, RetryAfter: 1500 * time.Millisecond}, http.StatusTooManyRequests},
+		{&circuitbreaker.Error{CommandType: renameType, State: circuitbreaker.StateOpen}, http.StatusServiceUnavailable},
+		{&command.TimeoutError{CommandType: renameType, Stage: command.StageHandle, Err: context.DeadlineExceeded}, http.StatusGatewayTimeout},
+		{errors.New("database password is wrong"), http.StatusInternalServerError},
+	} {
+		h := NewHandler(executerFunc(func(context.Context, command.Command) error { return c.err }))
+		w := post(h, "/commands/http.rename", "{}", nil)
+		if w.Code != c.status {
+			t.Error("unexpected status of", c.err, w.Code)
+		}
+
+		var response ErrorResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Error("the body should be an error response:", err)
+		}
+
+		switch c.status {
+		case http.StatusBadRequest:
+			if len(response.Fields) != 1 || response.Fields[0].Field != "content" {
+				t.Error("the validation errors should be returned:", response)
+			}
+		case http.StatusTooManyRequests:
+			if w.Header().Get("Retry-After") != "2" {
+				t.Error("the retry after hint should be returned:", w.Header())
+			}
+		case http.StatusInternalServerError:
+			if strings.Contains(response.Error, "password") {
+				t.Error("the internal errors should not be returned:", response)
+			}
+		}
+	}
+}
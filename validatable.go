@@ -1,6 +1,40 @@
 package command
 
+import "fmt"
+
 //CommandValidator command validator interface
 type CommandValidator interface {
 	Validate() error
 }
+
+// EntityValidator is a command with preconditions on the current state of its entity,
+// the executer calls ValidateAgainst after the entity is loaded and its version checked
+type EntityValidator interface {
+	ValidateAgainst(Entity) error
+}
+
+// PreconditionError error returned when EntityValidator.ValidateAgainst fails
+type PreconditionError struct {
+	CommandType Type
+	Err         error
+}
+
+// Error implements the error interface
+func (e *PreconditionError) Error() string {
+	return fmt.Sprintf("Precondition failed for command %s: %v", e.CommandType, e.Err)
+}
+
+// Unwrap returns the error returned by ValidateAgainst
+func (e *PreconditionError) Unwrap() error {
+	return e.Err
+}
+
+// validateAgainst checks the preconditions of the command if it is an EntityValidator
+func validateAgainst(cmd Command, entity Entity) error {
+	if v, ok := cmd.(EntityValidator); ok {
+		if err := v.ValidateAgainst(entity); err != nil {
+			return &PreconditionError{CommandType: cmd.CommandType(), Err: err}
+		}
+	}
+	return nil
+}
@@ -1,10 +1,16 @@
 package command
 
-import "errors"
+import (
+	"errors"
+	"reflect"
+)
 
 // ErrVersionMismatched error for version mismatched
 var ErrVersionMismatched = errors.New("Version mismatched")
 
+// ErrConcurrencyConflict error when the stored version changed after the entity was loaded
+var ErrConcurrencyConflict = errors.New("Concurrency conflict")
+
 // Versionable is an item that has a version number
 type Versionable interface {
 	Version() VersionType
@@ -12,3 +18,45 @@ type Versionable interface {
 
 // VersionType version type
 type VersionType uint64
+
+// ConcurrencyToken is an opaque value used for optimistic concurrency checks,
+// e.g. a VersionType, an ETag or a timestamp supplied by the database
+type ConcurrencyToken interface{}
+
+// ConcurrencyTokenable is an item that has a concurrency token,
+// it takes precedence over Versionable when an item implements both
+type ConcurrencyTokenable interface {
+	ConcurrencyToken() ConcurrencyToken
+}
+
+// TokenEqualer is a ConcurrencyToken with its own comparison
+type TokenEqualer interface {
+	Equal(ConcurrencyToken) bool
+}
+
+// TokenComparer returns true if the expected token matches the actual one
+type TokenComparer func(expected, actual ConcurrencyToken) bool
+
+// DefaultTokenComparer compares tokens with their Equal method if they implement TokenEqualer,
+// otherwise with reflect.DeepEqual
+func DefaultTokenComparer(expected, actual ConcurrencyToken) bool {
+	if e, ok := expected.(TokenEqualer); ok {
+		return e.Equal(actual)
+	}
+
+	return reflect.DeepEqual(expected, actual)
+}
+
+// ConcurrencyTokenOf returns the concurrency token of a command or an entity,
+// Versionable items use their version as token
+func ConcurrencyTokenOf(item interface{}) (ConcurrencyToken, bool) {
+	if t, ok := item.(ConcurrencyTokenable); ok {
+		return t.ConcurrencyToken(), true
+	}
+
+	if v, ok := item.(Versionable); ok {
+		return v.Version(), true
+	}
+
+	return nil, false
+}